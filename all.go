@@ -0,0 +1,40 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// DecodeAll splits bytes into records of template's type, which should have fixed size, and decodes them.
+// It returns pointers to records. Bytes length should be a multiple of record size
+func DecodeAll(bytes []byte, endian binary.ByteOrder, template interface{}) ([]interface{}, error) {
+	t := reflect.TypeOf(template)
+	if t == nil {
+		return nil, errors.New("template should not be nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	size, err := Size(reflect.Zero(t).Interface())
+	if err != nil {
+		return nil, errors.Wrap(err, "can't split records of variable size")
+	}
+	if size == 0 {
+		return nil, errors.Errorf("can't split records of %v, which take no bytes", t)
+	}
+	if len(bytes)%size != 0 {
+		return nil, errors.Errorf("data length %d is not a multiple of record size %d", len(bytes), size)
+	}
+	result := make([]interface{}, len(bytes)/size)
+	for i := range result {
+		record := reflect.New(t)
+		err = DecodeLimited(bytes[i*size:], endian, record.Interface(), size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't decode record %d", i)
+		}
+		result[i] = record.Interface()
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeAll(t *testing.T) {
+	Convey("Test DecodeAll", t, func() {
+		type Record struct {
+			ID    uint16
+			Value int32
+			Name  string `d2b:"length:4"`
+		}
+		Convey("Should decode many fixed size records", func() {
+			var data []byte
+			var expected []interface{}
+			for i := 0; i < 1000; i++ {
+				record := Record{ID: uint16(i), Value: int32(-i), Name: "rec"}
+				bytes, err := Encode(record, binary.LittleEndian)
+				So(err, ShouldBeNil)
+				data = append(data, bytes...)
+				expected = append(expected, &record)
+			}
+			records, err := DecodeAll(data, binary.LittleEndian, Record{})
+			So(err, ShouldBeNil)
+			So(records, ShouldResemble, expected)
+		})
+		Convey("Should return error if data length is not a multiple of record size", func() {
+			_, err := DecodeAll(make([]byte, 15), binary.LittleEndian, Record{})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for records of variable size", func() {
+			type Variable struct {
+				Len  uint8
+				Data []byte `d2b:"length_from:Len"`
+			}
+			_, err := DecodeAll([]byte{1, 'a', 1, 'b'}, binary.LittleEndian, Variable{})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "variable size")
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package d2b
+
+import (
+	"math/bits"
+
+	"github.com/pkg/errors"
+)
+
+// Options of 7-bit ASCII strings
+const (
+	ASCII7Strip = "true"
+	ASCII7Even  = "even"
+	ASCII7Odd   = "odd"
+)
+
+// parseASCII7 checks ascii7 option value
+func parseASCII7(value string) (string, error) {
+	switch value {
+	case ASCII7Strip, ASCII7Even, ASCII7Odd:
+		return value, nil
+	case "false":
+		return "", nil
+	}
+	return "", errors.Errorf("bad ascii7 option %q", value)
+}
+
+// stripHighBits returns copy of b with cleared high bits
+func stripHighBits(b []byte) []byte {
+	result := make([]byte, len(b))
+	for i, c := range b {
+		result[i] = c & 0x7f
+	}
+	return result
+}
+
+// setParityBits sets high bits of 7-bit characters in b, so every byte has even or odd count of set bits.
+// High bits are left clear, if mode is ASCII7Strip
+func setParityBits(b []byte, mode string) error {
+	for i, c := range b {
+		if c > 0x7f {
+			return errors.Errorf("character %#02x at %d is not 7-bit ASCII", c, i)
+		}
+		ones := bits.OnesCount8(c)
+		if mode == ASCII7Even && ones%2 == 1 || mode == ASCII7Odd && ones%2 == 0 {
+			b[i] = c | 0x80
+		}
+	}
+	return nil
+}
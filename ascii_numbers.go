@@ -0,0 +1,174 @@
+package d2b
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Justifications of numbers, stored as ASCII text
+const (
+	JustifyLeft  = "left"
+	JustifyRight = "right"
+)
+
+// checkASCIINumber checks, that field with ascii option is a number of fixed length
+func checkASCIINumber(t reflect.Type, tag *structFieldTag) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !isInteger(t.Kind()) && t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64 {
+		return errors.Errorf("ascii field should be number, not %v", t)
+	}
+	if tag.Length <= 0 || tag.LengthFrom != "" {
+		return errors.New("ascii field should declare length")
+	}
+	switch tag.Justify {
+	case "", JustifyRight:
+		return nil
+	case JustifyLeft:
+		// trailing digit padding can't be told apart from digits of number
+		if pad := tag.asciiPad(); pad >= '0' && pad <= '9' {
+			return errors.Errorf("left justified ascii field can't be padded with digit %q", pad)
+		}
+		return nil
+	}
+	return errors.Errorf("bad justify %q", tag.Justify)
+}
+
+// asciiPad returns character, which fills unused part of ASCII number. Default is space
+func (t *structFieldTag) asciiPad() byte {
+	if t.Pad == "" {
+		return ' '
+	}
+	return t.Pad[0]
+}
+
+// decodeASCIINumber parses number, stored as ASCII text of declared length
+func (d *Decoder) decodeASCIINumber(v reflect.Value, tags *structFieldTag) error {
+	b, err := d.next(tags.Length)
+	if err != nil {
+		return err
+	}
+	pad := string([]byte{tags.asciiPad()})
+	text := strings.TrimLeft(string(b), pad)
+	if tags.Justify == JustifyLeft {
+		text = strings.TrimRight(string(b), pad)
+	}
+	if text == "" || text == "-" {
+		// number, which consists of zero padding only
+		text += "0"
+	}
+	switch {
+	case isSigned(v.Kind()):
+		value, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return errors.Wrapf(err, "bad ascii number %q", b)
+		}
+		v.SetInt(value)
+	case isInteger(v.Kind()):
+		value, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return errors.Wrapf(err, "bad ascii number %q", b)
+		}
+		v.SetUint(value)
+	default:
+		value, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return errors.Wrapf(err, "bad ascii number %q", b)
+		}
+		v.SetFloat(value)
+	}
+	return nil
+}
+
+// asciiNumberToBytes writes number as ASCII text, justified and padded to declared length.
+// Floats are written as the shortest decimal, which is parsed back to the same value
+func asciiNumberToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) error {
+	var text string
+	switch {
+	case isSigned(v.Kind()):
+		text = strconv.FormatInt(v.Int(), 10)
+	case isInteger(v.Kind()):
+		text = strconv.FormatUint(v.Uint(), 10)
+	default:
+		text = strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+	if len(text) > ft.Length {
+		return errors.Errorf("ascii number %s doesn't fit %d characters", text, ft.Length)
+	}
+	padding := strings.Repeat(string([]byte{ft.asciiPad()}), ft.Length-len(text))
+	switch {
+	case ft.Justify == JustifyLeft:
+		text += padding
+	case ft.asciiPad() == '0' && strings.HasPrefix(text, "-"):
+		// zeros go after the sign
+		text = "-" + padding + text[1:]
+	default:
+		text = padding + text
+	}
+	buffer.WriteString(text)
+	return nil
+}
+
+// checkASCIILength checks, that field with length_ascii option is string or []byte and digits count is valid
+func checkASCIILength(t reflect.Type, digits int) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String && (t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8) {
+		return errors.Errorf("length_ascii field should be string or []byte, not %v", t)
+	}
+	if digits < 1 || digits > 18 {
+		return errors.Errorf("bad length_ascii digits count %d", digits)
+	}
+	return nil
+}
+
+// decodeASCIILength reads length, stored as ASCII decimal digits, followed by that many bytes of field
+func (d *Decoder) decodeASCIILength(v reflect.Value, tags *structFieldTag) error {
+	digits, err := d.next(tags.LengthASCII)
+	if err != nil {
+		return err
+	}
+	var length int
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return errors.Errorf("bad ascii length %q", digits)
+		}
+		length = length*10 + int(c-'0')
+	}
+	if v.Kind() == reflect.String && length > d.maxStringLen() {
+		return errors.Errorf("string length %d exceeds limit %d", length, d.maxStringLen())
+	}
+	b, err := d.next(length)
+	if err != nil {
+		return err
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(d.bytesToStr(b))
+		return nil
+	}
+	v.SetBytes(append([]byte{}, b...))
+	return nil
+}
+
+// asciiLengthToBytes writes length of field as zero padded ASCII decimal digits, followed by field bytes
+func asciiLengthToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) error {
+	var b []byte
+	if v.Kind() == reflect.String {
+		b = []byte(v.String())
+	} else {
+		b = v.Bytes()
+	}
+	length := strconv.Itoa(len(b))
+	if len(length) > ft.LengthASCII {
+		return errors.Errorf("length %s doesn't fit %d digits", length, ft.LengthASCII)
+	}
+	buffer.WriteString(strings.Repeat("0", ft.LengthASCII-len(length)) + length)
+	buffer.Write(b)
+	return nil
+}
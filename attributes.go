@@ -0,0 +1,121 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// DecodeAttributes reads list of [tag][length][value] attributes, which takes the whole bytes, and
+// returns raw values by tags. Tag and length are unsigned integers of tagWidth and lengthWidth bytes
+func DecodeAttributes(bytes []byte, endian binary.ByteOrder, tagWidth, lengthWidth int) (map[uint64][]byte, error) {
+	for _, width := range []int{tagWidth, lengthWidth} {
+		switch width {
+		case 1, 2, 4, 8:
+		default:
+			return nil, errors.Errorf("bad width %d", width)
+		}
+	}
+	d := NewDecoder(bytes, endian)
+	result := make(map[uint64][]byte)
+	for d.Remaining() > 0 {
+		start := d.offset
+		b, err := d.next(tagWidth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't read attribute tag at offset %d", start)
+		}
+		tag := readUint(b, endian)
+		b, err = d.next(lengthWidth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't read attribute %d length", tag)
+		}
+		length := readUint(b, endian)
+		if length > uint64(d.Remaining()) {
+			return nil, errors.Errorf("attribute %d length %d exceeds %d remaining bytes", tag, length, d.Remaining())
+		}
+		if _, ok := result[tag]; ok {
+			return nil, errors.Errorf("attribute %d is duplicated at offset %d", tag, start)
+		}
+		result[tag], _ = d.next(int(length))
+	}
+	return result, nil
+}
+
+// DecodeTLV reads list of attributes like DecodeAttributes and sets their values to fields of data struct
+// with tlv:<tag> option. Values of []byte and string fields are set as is, other fields are decoded from
+// values. Attributes with other tags are put to map[integer][]byte field with tlv_rest option, if it's declared
+func DecodeTLV(bytes []byte, endian binary.ByteOrder, tagWidth, lengthWidth int, data interface{}) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("data should be non-nil pointer to struct")
+	}
+	v = v.Elem()
+	tags, err := getStructTags(v.Type(), DefaultTagKey)
+	if err != nil {
+		return errors.Wrapf(err, "parsing %v struct tags error", v.Type().Name())
+	}
+	attributes, err := DecodeAttributes(bytes, endian, tagWidth, lengthWidth)
+	if err != nil {
+		return err
+	}
+	rest := -1
+	for i, tag := range tags {
+		if tag.TLVRest {
+			rest = i
+		}
+		if tag.TLV == -1 {
+			continue
+		}
+		value, ok := attributes[uint64(tag.TLV)]
+		if !ok {
+			continue
+		}
+		delete(attributes, uint64(tag.TLV))
+		err = setTLVField(v.Field(i), value, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't set %v.%v field", v.Type().Name(), v.Type().Field(i).Name)
+		}
+	}
+	if rest == -1 || len(attributes) == 0 {
+		return nil
+	}
+	field := v.Field(rest)
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	for tag, value := range attributes {
+		key := reflect.New(field.Type().Key()).Elem()
+		if isSigned(key.Kind()) {
+			key.SetInt(int64(tag))
+		} else {
+			key.SetUint(tag)
+		}
+		if uintValue(key) != tag {
+			return errors.Errorf("attribute tag %d doesn't fit %v key", tag, key.Type())
+		}
+		field.SetMapIndex(key, reflect.ValueOf(value))
+	}
+	return nil
+}
+
+// setTLVField sets attribute value to field v
+func setTLVField(v reflect.Value, value []byte, endian binary.ByteOrder) error {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(string(value))
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v.SetBytes(append([]byte{}, value...))
+		return nil
+	}
+	return Decode(value, endian, v.Addr().Interface())
+}
+
+// checkTLVRest checks, that field with tlv_rest option is map of integers to []byte
+func checkTLVRest(t reflect.Type) error {
+	if t.Kind() != reflect.Map || !isInteger(t.Key().Kind()) || t.Elem() != reflect.TypeOf([]byte{}) {
+		return errors.Errorf("tlv_rest field should be map[integer][]byte, not %v", t)
+	}
+	return nil
+}
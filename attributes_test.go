@@ -0,0 +1,78 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeAttributes(t *testing.T) {
+	Convey("Test DecodeAttributes", t, func() {
+		Convey("Should return raw values by tags", func() {
+			attributes, err := DecodeAttributes([]byte{
+				1, 0, 2, 'h', 'i',
+				2, 0, 0,
+				0x10, 0, 4, 1, 2, 3, 4,
+			}, binary.BigEndian, 1, 2)
+			So(err, ShouldBeNil)
+			So(attributes, ShouldResemble, map[uint64][]byte{
+				1:    []byte("hi"),
+				2:    {},
+				0x10: {1, 2, 3, 4},
+			})
+		})
+		Convey("Should return error for truncated attributes", func() {
+			_, err := DecodeAttributes([]byte{1, 0, 3, 'h', 'i'}, binary.BigEndian, 1, 2)
+			So(err, ShouldNotBeNil)
+			_, err = DecodeAttributes([]byte{1, 0}, binary.BigEndian, 1, 2)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for duplicated tags", func() {
+			_, err := DecodeAttributes([]byte{1, 1, 'a', 1, 1, 'b'}, binary.BigEndian, 1, 1)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for bad widths", func() {
+			_, err := DecodeAttributes([]byte{1, 1, 'a'}, binary.BigEndian, 3, 1)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestDecodeTLV(t *testing.T) {
+	Convey("Test DecodeTLV", t, func() {
+		type Options struct {
+			Name    string            `d2b:"tlv:1"`
+			MTU     uint16            `d2b:"tlv:2"`
+			Cookie  []byte            `d2b:"tlv:3"`
+			Unknown map[uint16][]byte `d2b:"tlv_rest:true"`
+		}
+		wire := []byte{
+			0, 1, 0, 3, 'e', 't', 'h',
+			0, 9, 0, 1, 0xaa,
+			0, 2, 0, 2, 0x05, 0xdc,
+			1, 0, 0, 0,
+		}
+		Convey("Should set known attributes to fields and unknown ones to map", func() {
+			var result Options
+			err := DecodeTLV(wire, binary.BigEndian, 2, 2, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Options{
+				Name:    "eth",
+				MTU:     1500,
+				Unknown: map[uint16][]byte{9: {0xaa}, 0x100: {}},
+			})
+		})
+		Convey("Should return error if known attribute can't be decoded", func() {
+			var result Options
+			err := DecodeTLV([]byte{0, 2, 0, 1, 5}, binary.BigEndian, 2, 2, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for bad tlv_rest field", func() {
+			type Bad struct {
+				Rest map[string][]byte `d2b:"tlv_rest:true"`
+			}
+			So(DecodeTLV(wire, binary.BigEndian, 2, 2, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
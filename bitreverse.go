@@ -0,0 +1,70 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// checkBitReverse checks, that field with bitreverse option is integer or bytes
+func checkBitReverse(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isInteger(t.Kind()) {
+		return nil
+	}
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8 {
+		return nil
+	}
+	return errors.Errorf("bitreverse field should be integer or bytes, not %v", t)
+}
+
+// reverseBits reverses order of bits in every byte of b
+func reverseBits(b []byte) {
+	for i, c := range b {
+		b[i] = bits.Reverse8(c)
+	}
+}
+
+// decodeBitReversed decodes field from bytes, which bits are reversed
+func (d *Decoder) decodeBitReversed(parent, v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	var length int
+	var err error
+	if t := v.Type(); t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Slice {
+		length, err = tags.length(parent)
+	} else {
+		length, err = (&Encoder{TagKey: d.TagKey, Version: d.Version}).getStructFieldTypeBytesLength(v.Type(), tags)
+	}
+	if err != nil {
+		return err
+	}
+	b, err := d.next(length)
+	if err != nil {
+		return err
+	}
+	reversed := make([]byte, length)
+	copy(reversed, b)
+	reverseBits(reversed)
+	sub := *d
+	sub.bytes, sub.buffers, sub.reader, sub.offset = reversed, nil, nil, 0
+	fieldTags := *tags
+	fieldTags.BitReverse = false
+	return sub.decodeStructField(parent, v, &fieldTags, endian)
+}
+
+// bitReversedToBytes writes field and reverses bits of its bytes
+func (e *Encoder) bitReversedToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	start := buffer.Len()
+	fieldTags := *ft
+	fieldTags.BitReverse = false
+	err := e.structFieldValueToBytes(parent, v, &fieldTags, buffer, endian)
+	if err != nil {
+		return err
+	}
+	reverseBits(buffer.Bytes()[start:])
+	return nil
+}
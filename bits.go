@@ -0,0 +1,292 @@
+package d2b
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Bit orders of bits fields
+const (
+	BitOrderLSB = "lsb"
+	BitOrderMSB = "msb"
+)
+
+// bitsLength returns count of bytes, which contain given count of bits
+func bitsLength(bits int) int {
+	return (bits + 7) / 8
+}
+
+// bitMask returns mask of i-th bit in its byte
+func bitMask(i int, order string) byte {
+	if order == BitOrderMSB {
+		return 0x80 >> uint(i%8)
+	}
+	return 1 << uint(i%8)
+}
+
+// decodeBits decodes bit flags to []bool field. Every bool takes one bit, starting from the least
+// significant one, or the most significant one if msb bit order is set
+func (d *Decoder) decodeBits(v reflect.Value, tags *structFieldTag) error {
+	b, err := d.next(bitsLength(tags.Bits))
+	if err != nil {
+		return err
+	}
+	result := d.makeSlice(v.Type(), tags.Bits)
+	for i := 0; i < tags.Bits; i++ {
+		result.Index(i).SetBool(b[i/8]&bitMask(i, tags.BitOrder) != 0)
+	}
+	v.Set(result)
+	return nil
+}
+
+// bitsToBytes packs []bool field to bit flags
+func (e *Encoder) bitsToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) error {
+	if v.Len() > ft.Bits {
+		return errors.Errorf("bits field contains %d flags, but only %d bits are declared", v.Len(), ft.Bits)
+	}
+	b := make([]byte, bitsLength(ft.Bits))
+	for i := 0; i < v.Len(); i++ {
+		if v.Index(i).Bool() {
+			b[i/8] |= bitMask(i, ft.BitOrder)
+		}
+	}
+	buffer.Write(b)
+	return nil
+}
+
+// decodeBitmap decodes [N]bool field, stored as bitmap of N bits
+func (d *Decoder) decodeBitmap(v reflect.Value, tags *structFieldTag) error {
+	b, err := d.next(bitsLength(v.Len()))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		v.Index(i).SetBool(b[i/8]&bitMask(i, tags.BitOrder) != 0)
+	}
+	return nil
+}
+
+// bitmapToBytes packs [N]bool field to bitmap of N bits
+func bitmapToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) {
+	b := make([]byte, bitsLength(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if v.Index(i).Bool() {
+			b[i/8] |= bitMask(i, ft.BitOrder)
+		}
+	}
+	buffer.Write(b)
+}
+
+// checkBitmap checks, that field with bitmap option is [N]bool
+func checkBitmap(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Array || t.Elem().Kind() != reflect.Bool {
+		return errors.Errorf("bitmap field should be bool array, not %v", t)
+	}
+	return nil
+}
+
+// decodeFlags decodes bit flags to struct of bools. Every field takes one bit in declaration order,
+// blank fields are reserved bits
+func (d *Decoder) decodeFlags(v reflect.Value, tags *structFieldTag) error {
+	b, err := d.next(bitsLength(v.NumField()))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).CanSet() {
+			v.Field(i).SetBool(b[i/8]&bitMask(i, tags.BitOrder) != 0)
+		}
+	}
+	return nil
+}
+
+// flagsToBytes packs struct of bools to bit flags, one bit per field
+func flagsToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) {
+	b := make([]byte, bitsLength(v.NumField()))
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Name != "_" && v.Field(i).Bool() {
+			b[i/8] |= bitMask(i, ft.BitOrder)
+		}
+	}
+	buffer.Write(b)
+}
+
+// checkFlags checks, that field with flags option is struct of exported or blank bools
+func checkFlags(t reflect.Type) error {
+	if t.Kind() != reflect.Struct {
+		return errors.Errorf("flags field should be struct of bools, not %v", t)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() != reflect.Bool {
+			return errors.Errorf("flags field %s should be bool, not %v", field.Name, field.Type)
+		}
+		if field.PkgPath != "" && field.Name != "_" {
+			return errors.Errorf("flags field %s should be exported", field.Name)
+		}
+	}
+	return nil
+}
+
+// checkBits checks, that field with bits option is []bool or integer, which has at least declared count of bits
+func checkBits(t reflect.Type, bits int) error {
+	if bits <= 0 {
+		return errors.Errorf("bits should be positive, not %d", bits)
+	}
+	if isInteger(t.Kind()) {
+		if bits > t.Bits() {
+			return errors.Errorf("%v bit field can't take %d bits", t, bits)
+		}
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Bool {
+		return errors.Errorf("bits field should be []bool or integer, not %v", t)
+	}
+	return nil
+}
+
+// Consecutive integer fields with bits option form a group of bit fields, which are packed to a bit stream
+// without gaps. With lsb bit order the first field takes the least significant bits of the first byte and
+// values are stored least significant bit first. With msb bit order the first field starts at the most
+// significant bit and values are stored most significant bit first, like big-endian registers. The last
+// byte of group is padded with zero bits. The first field of group reads and writes the whole group
+
+// isBitField returns true if field is an integer bit field
+func isBitField(t reflect.Type, tag *structFieldTag) bool {
+	return tag.Bits != 0 && isInteger(t.Kind())
+}
+
+// groupBitFields finds groups of consecutive integer bit fields and sets bit offsets of fields and fields
+// of group to its first field
+func groupBitFields(structType reflect.Type, tags []*structFieldTag, order []int) error {
+	var head *structFieldTag
+	for _, i := range order {
+		tag := tags[i]
+		if tag.Skip {
+			continue
+		}
+		if !isBitField(structType.Field(i).Type, tag) {
+			head = nil
+			continue
+		}
+		if tag.Union || tag.Range != "" || tag.Since != 0 || tag.Until != 0 || tag.OmitEmpty {
+			return errors.Errorf("%v bit field can't be union, range, versioned or omit_empty field", structType.Field(i).Name)
+		}
+		if head == nil {
+			head = tag
+		} else if tag.BitOrder != head.BitOrder {
+			return errors.Errorf("%v bit field should have the same bit order as previous ones", structType.Field(i).Name)
+		}
+		tag.bitOffset = head.bitGroupLen
+		head.bitGroup = append(head.bitGroup, i)
+		head.bitGroupLen += tag.Bits
+	}
+	for _, tag := range tags {
+		tag.bitGroupLen = bitsLength(tag.bitGroupLen)
+	}
+	return nil
+}
+
+// readBits reads count bits, starting at bit offset of b
+func readBits(b []byte, offset, count int, order string) uint64 {
+	var value uint64
+	for k := 0; k < count; k++ {
+		pos := offset + k
+		if b[pos/8]&bitMask(pos, order) == 0 {
+			continue
+		}
+		if order == BitOrderMSB {
+			value |= 1 << uint(count-1-k)
+		} else {
+			value |= 1 << uint(k)
+		}
+	}
+	return value
+}
+
+// writeBits writes count low bits of value, starting at bit offset of b
+func writeBits(b []byte, offset, count int, order string, value uint64) {
+	for k := 0; k < count; k++ {
+		bit := uint(k)
+		if order == BitOrderMSB {
+			bit = uint(count - 1 - k)
+		}
+		if value&(1<<bit) != 0 {
+			pos := offset + k
+			b[pos/8] |= bitMask(pos, order)
+		}
+	}
+}
+
+// decodeBitFields reads bytes of bit fields group, which starts with field with given tags, and sets all
+// fields of group. Other fields of group take no bytes
+func (d *Decoder) decodeBitFields(parent reflect.Value, tags *structFieldTag) error {
+	if tags.bitGroup == nil {
+		return nil
+	}
+	b, err := d.next(tags.bitGroupLen)
+	if err != nil {
+		return err
+	}
+	info, err := getStructInfo(parent.Type(), d.TagKey)
+	if err != nil {
+		return err
+	}
+	for _, i := range tags.bitGroup {
+		tag, v := info.tags[i], parent.Field(i)
+		value := readBits(b, tag.bitOffset, tag.Bits, tags.BitOrder)
+		if !isSigned(v.Kind()) {
+			v.SetUint(value)
+			continue
+		}
+		// sign extension
+		if tag.Bits < 64 && value&(1<<uint(tag.Bits-1)) != 0 {
+			value |= ^uint64(0) << uint(tag.Bits)
+		}
+		v.SetInt(int64(value))
+	}
+	return nil
+}
+
+// bitFieldsToBytes writes bytes of bit fields group, which starts with field with given tags. Other fields
+// of group write nothing
+func (e *Encoder) bitFieldsToBytes(parent reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) error {
+	if ft.bitGroup == nil {
+		return nil
+	}
+	info, err := getStructInfo(parent.Type(), e.TagKey)
+	if err != nil {
+		return err
+	}
+	b := make([]byte, ft.bitGroupLen)
+	for _, i := range ft.bitGroup {
+		tag, v := info.tags[i], parent.Field(i)
+		var value uint64
+		if isSigned(v.Kind()) {
+			value = uint64(v.Int())
+			if tag.Bits < 64 {
+				max := int64(1)<<uint(tag.Bits-1) - 1
+				if v.Int() > max || v.Int() < -max-1 {
+					return errors.Errorf("%s value %d doesn't fit %d bits", parent.Type().Field(i).Name, v.Int(), tag.Bits)
+				}
+				value &= 1<<uint(tag.Bits) - 1
+			}
+		} else {
+			value = v.Uint()
+			if tag.Bits < 64 && value >= 1<<uint(tag.Bits) {
+				return errors.Errorf("%s value %d doesn't fit %d bits", parent.Type().Field(i).Name, value, tag.Bits)
+			}
+		}
+		writeBits(b, tag.bitOffset, tag.Bits, ft.BitOrder, value)
+	}
+	buffer.Write(b)
+	return nil
+}
@@ -0,0 +1,257 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBits(t *testing.T) {
+	Convey("Test bit flags fields", t, func() {
+		type Flags struct {
+			LSB []bool `d2b:"bits:8"`
+			MSB []bool `d2b:"bits:10,bit_order:msb"`
+		}
+		data := Flags{
+			LSB: []bool{true, false, true, true, false, false, false, true},
+			MSB: []bool{true, false, false, false, false, false, false, true, false, true},
+		}
+		wire := []byte{0x8d, 0x81, 0x40}
+		Convey("Should decode flags byte into bools", func() {
+			var result Flags
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should pack bools into flags", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+			bytes, err = Encode(Flags{LSB: []bool{false, true}}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x02, 0, 0})
+		})
+		Convey("Should return error if there are more bools than bits", func() {
+			_, err := Encode(Flags{LSB: make([]bool, 9)}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for bits on non-bool slice", func() {
+			type Bad struct {
+				A []uint8 `d2b:"bits:8"`
+			}
+			So(Decode(wire, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+		})
+		Convey("Should return error for non-positive bits", func() {
+			type Bad struct {
+				A []bool `d2b:"bits:-1"`
+			}
+			So(Decode(wire, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+			_, err := Encode(Bad{A: []bool{true}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBitReverse(t *testing.T) {
+	Convey("Test bit reversed fields", t, func() {
+		type Frame struct {
+			Value uint16  `d2b:"bitreverse:true"`
+			Len   uint8   `d2b:"bitreverse:true"`
+			Data  []byte  `d2b:"length_from:Len,bitreverse:true"`
+			Raw   [2]byte `d2b:"bitreverse:true"`
+			Plain uint8
+		}
+		data := Frame{Value: 0x1234, Len: 2, Data: []byte{0x01, 0x80}, Raw: [2]byte{0x0f, 0xa0}, Plain: 0x01}
+		wire := []byte{0x48, 0x2c, 0x40, 0x80, 0x01, 0xf0, 0x05, 0x01}
+		Convey("Should decode bit reversed bytes", func() {
+			var result Frame
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should encode bit reversed bytes", func() {
+			bytes, err := Encode(data, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error for bitreverse on float field", func() {
+			type Bad struct {
+				A float32 `d2b:"bitreverse:true"`
+			}
+			So(Decode(make([]byte, 4), binary.BigEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBitFields(t *testing.T) {
+	Convey("Test integer bit fields", t, func() {
+		type MSBRegister struct {
+			A uint8  `d2b:"bits:4,bit_order:msb"`
+			B uint16 `d2b:"bits:12,bit_order:msb"`
+			C uint32 `d2b:"bits:20,bit_order:msb"`
+			D int32  `d2b:"bits:28,bit_order:msb"`
+		}
+		type LSBRegister struct {
+			A uint8  `d2b:"bits:4"`
+			B uint16 `d2b:"bits:12"`
+			C uint32 `d2b:"bits:20"`
+			D int32  `d2b:"bits:28"`
+		}
+		Convey("Should pack 12, 20 and 28-bit fields most significant bit first", func() {
+			register := MSBRegister{A: 0xa, B: 0xbcd, C: 0x12345, D: -2}
+			wire := []byte{0xab, 0xcd, 0x12, 0x34, 0x5f, 0xff, 0xff, 0xfe}
+			bytes, err := Encode(register, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+			var decoded MSBRegister
+			err = Decode(wire, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, register)
+		})
+		Convey("Should pack 12, 20 and 28-bit fields least significant bit first", func() {
+			register := LSBRegister{A: 0xa, B: 0xbcd, C: 0x12345, D: -2}
+			wire := []byte{0xda, 0xbc, 0x45, 0x23, 0xe1, 0xff, 0xff, 0xff}
+			bytes, err := Encode(register, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+			var decoded LSBRegister
+			err = Decode(wire, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, register)
+		})
+		Convey("Should pad group to whole bytes", func() {
+			type Packed struct {
+				X    uint8  `d2b:"bits:3,bit_order:msb"`
+				Y    uint16 `d2b:"bits:12,bit_order:msb"`
+				Tail uint8
+			}
+			packed := Packed{X: 5, Y: 0xabc, Tail: 7}
+			bytes, err := Encode(packed, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0xb5, 0x78, 7})
+			var decoded Packed
+			err = Decode(bytes, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, packed)
+			size, err := Size(packed)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, 3)
+		})
+		Convey("Should return error if value doesn't fit its bits", func() {
+			_, err := Encode(LSBRegister{B: 0x1000}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(LSBRegister{D: 1 << 27}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if field has less bits than declared", func() {
+			type Bad struct {
+				A uint8 `d2b:"bits:12"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBitmap(t *testing.T) {
+	Convey("Test bitmap option", t, func() {
+		type Status struct {
+			Ready [10]bool `d2b:"bitmap:true"`
+			Alarm [3]bool  `d2b:"bitmap:true,bit_order:msb"`
+			Code  uint8
+		}
+		status := Status{
+			Ready: [10]bool{true, false, true, false, false, false, false, true, false, true},
+			Alarm: [3]bool{true, false, true},
+			Code:  7,
+		}
+		wire := []byte{0x85, 0x02, 0xa0, 7}
+		Convey("Should pack [10]bool to 2 bytes", func() {
+			bytes, err := Encode(status, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+			size, err := Size(status)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, 4)
+		})
+		Convey("Should decode bitmap to bools", func() {
+			var decoded Status
+			err := Decode(wire, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, status)
+		})
+		Convey("Should return error for bitmap on non-bool array", func() {
+			type Bad struct {
+				Flags [8]uint8 `d2b:"bitmap:true"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestFlags(t *testing.T) {
+	Convey("Test flags option", t, func() {
+		type Permissions struct {
+			Read    bool
+			Write   bool
+			_       bool
+			Execute bool
+		}
+		type Options struct {
+			Compressed bool
+			Encrypted  bool
+		}
+		type File struct {
+			Mode    Permissions `d2b:"flags:true"`
+			Options Options     `d2b:"flags:true,bit_order:msb"`
+			Size    uint16
+		}
+		file := File{
+			Mode:    Permissions{Read: true, Execute: true},
+			Options: Options{Encrypted: true},
+			Size:    512,
+		}
+		wire := []byte{0x09, 0x40, 0x02, 0x00}
+		Convey("Should pack named flags to byte", func() {
+			bytes, err := Encode(file, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+			size, err := Size(file)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, 4)
+		})
+		Convey("Should decode byte to named flags", func() {
+			var decoded File
+			err := Decode([]byte{0x0d, 0x40, 0x02, 0x00}, binary.BigEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, file)
+		})
+		Convey("Should return error for non-bool flags", func() {
+			type Bad struct {
+				Mode struct {
+					Read  bool
+					Level uint8
+				} `d2b:"flags:true"`
+			}
+			_, err := Encode(Bad{}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for pointer and unexported flags", func() {
+			type Pointer struct {
+				Options *Options `d2b:"flags:true"`
+			}
+			_, err := Encode(Pointer{}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+			type Unexported struct {
+				Mode struct {
+					Read   bool
+					hidden bool
+				} `d2b:"flags:true"`
+			}
+			_, err = Encode(Unexported{}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
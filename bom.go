@@ -0,0 +1,113 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// byteOrderMark is a value of byte order mark fields
+const byteOrderMark = 0xfeff
+
+// bomEndian returns byte order of fields after decoded byte order mark field v. Mark, which was decoded
+// with swapped bytes, switches byte order to another one. Field is set to the mark value
+func bomEndian(v reflect.Value, endian binary.ByteOrder) (binary.ByteOrder, error) {
+	switch v.Uint() {
+	case byteOrderMark:
+		return endian, nil
+	case 0xfffe:
+		v.SetUint(byteOrderMark)
+		if endian == binary.BigEndian {
+			return binary.LittleEndian, nil
+		}
+		return binary.BigEndian, nil
+	}
+	return nil, errors.Errorf("bad byte order mark %#04x", v.Uint())
+}
+
+// bomToBytes writes byte order mark with given byte order, regardless of field value
+func bomToBytes(buffer *bytes.Buffer, endian binary.ByteOrder) {
+	b := make([]byte, 2)
+	endian.PutUint16(b, byteOrderMark)
+	buffer.Write(b)
+}
+
+// flagEndian returns byte order, selected by endian flag field v: little-endian if it's true bool or its
+// bit 0 is set, big-endian otherwise
+func flagEndian(v reflect.Value) binary.ByteOrder {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Bool && v.Bool() || v.Kind() != reflect.Bool && uintValue(v)&1 != 0 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// resolveEndianFrom returns index of previous bool or integer field, which selects byte order with
+// endian_from option
+func resolveEndianFrom(structType reflect.Type, previous []int, name string) (int, error) {
+	for _, i := range previous {
+		ft := structType.Field(i)
+		if ft.Name != name {
+			continue
+		}
+		t := ft.Type
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Bool && !isInteger(t.Kind()) {
+			return 0, errors.Errorf("endian_from field %s should be bool or integer", name)
+		}
+		return i, nil
+	}
+	return 0, errors.Errorf("endian_from field %s should be declared before", name)
+}
+
+// checkBOM checks, that field with bom option is uint16
+func checkBOM(t reflect.Type) error {
+	if t.Kind() != reflect.Uint16 {
+		return errors.Errorf("byte order mark field should be uint16, not %v", t)
+	}
+	return nil
+}
+
+// parseAlternateEndian parses byte orders of even and odd elements, e.g. big/little
+func parseAlternateEndian(value string) ([]binary.ByteOrder, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return nil, errors.Errorf("bad alternate endian %q, should be Even/Odd", value)
+	}
+	result := make([]binary.ByteOrder, len(parts))
+	for i, part := range parts {
+		var err error
+		result[i], err = parseEndian(part)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// elemEndian returns byte order of element i of slice or array. Elements of field with endian_alternate
+// option use byte orders in turn
+func (t *structFieldTag) elemEndian(i int, endian binary.ByteOrder) binary.ByteOrder {
+	if len(t.AltEndian) == 0 {
+		return endian
+	}
+	return t.AltEndian[i%len(t.AltEndian)]
+}
+
+// checkAlternateEndian checks, that field with endian_alternate option is slice or array
+func checkAlternateEndian(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return errors.Errorf("field with endian_alternate option should be slice or array, not %v", t)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBOM(t *testing.T) {
+	Convey("Test byte order mark fields", t, func() {
+		type Header struct {
+			Version uint16
+		}
+		type File struct {
+			Mark   uint16 `d2b:"bom:true"`
+			Header Header
+			Size   uint32
+		}
+		expected := File{Mark: 0xfeff, Header: Header{Version: 2}, Size: 0x0a0b0c0d}
+		Convey("Should decode payload with big endian mark", func() {
+			var result File
+			err := Decode([]byte{0xfe, 0xff, 0, 2, 0x0a, 0x0b, 0x0c, 0x0d}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, expected)
+		})
+		Convey("Should decode payload with little endian mark", func() {
+			var result File
+			err := Decode([]byte{0xff, 0xfe, 2, 0, 0x0d, 0x0c, 0x0b, 0x0a}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, expected)
+		})
+		Convey("Should encode mark of requested byte order", func() {
+			bytes, err := Encode(File{Header: Header{Version: 2}}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0xfe, 0xff, 0, 2, 0, 0, 0, 0})
+			bytes, err = Encode(File{Header: Header{Version: 2}}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0xff, 0xfe, 2, 0, 0, 0, 0, 0})
+		})
+		Convey("Should return error for bad mark", func() {
+			var result File
+			err := Decode([]byte{1, 2, 0, 2, 0, 0, 0, 0}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestEndianFrom(t *testing.T) {
+	Convey("Test endian_from option", t, func() {
+		type Header struct {
+			Flags  uint8
+			Length uint16 `d2b:"endian_from:Flags"`
+			ID     uint32
+			Magic  uint16 `d2b:"endian:big"`
+		}
+		Convey("Should decode following fields little-endian if flag bit 0 is set", func() {
+			wire := []byte{0x03, 0x02, 0x01, 0x04, 0x03, 0x02, 0x01, 0xca, 0xfe}
+			var header Header
+			err := Decode(wire, binary.BigEndian, &header)
+			So(err, ShouldBeNil)
+			So(header, ShouldResemble, Header{Flags: 3, Length: 0x0102, ID: 0x01020304, Magic: 0xcafe})
+			bytes, err := Encode(header, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should decode following fields big-endian if flag bit 0 is clear", func() {
+			wire := []byte{0x02, 0x01, 0x02, 0x01, 0x02, 0x03, 0x04, 0xca, 0xfe}
+			var header Header
+			err := Decode(wire, binary.LittleEndian, &header)
+			So(err, ShouldBeNil)
+			So(header, ShouldResemble, Header{Flags: 2, Length: 0x0102, ID: 0x01020304, Magic: 0xcafe})
+			bytes, err := Encode(header, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should select byte order with bool flag", func() {
+			type Record struct {
+				Little bool
+				Length uint16 `d2b:"endian_from:Little"`
+			}
+			var record Record
+			err := Decode([]byte{1, 0x02, 0x01}, binary.BigEndian, &record)
+			So(err, ShouldBeNil)
+			So(record, ShouldResemble, Record{Little: true, Length: 0x0102})
+			bytes, err := Encode(Record{Length: 0x0102}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 0x01, 0x02})
+		})
+		Convey("Should plan fields after flag with its byte order", func() {
+			plans, err := NewEncoder(nil).Plan(Header{})
+			So(err, ShouldBeNil)
+			So(plans[0].EndianFrom, ShouldBeEmpty)
+			So(plans[1].EndianFrom, ShouldEqual, "Flags")
+			So(plans[2].EndianFrom, ShouldEqual, "Flags")
+			So(plans[2].Endian, ShouldBeNil)
+			So(plans[3].EndianFrom, ShouldBeEmpty)
+			So(plans[3].Endian, ShouldResemble, binary.BigEndian)
+			layout, err := LayoutJSON(Header{})
+			So(err, ShouldBeNil)
+			So(string(layout), ShouldContainSubstring, `{"name":"ID","offset":3,"size":4,"kind":"uint32","endian_from":"Flags"}`)
+		})
+		Convey("Should return error if flag field isn't declared before", func() {
+			type Bad struct {
+				Length uint16 `d2b:"endian_from:Flags"`
+				Flags  uint8
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestEndianAlternate(t *testing.T) {
+	Convey("Test endian_alternate option", t, func() {
+		wire := []byte{0x00, 0x01, 0x02, 0x00, 0x00, 0x03, 0x04, 0x00}
+		Convey("Should decode slice elements with alternating byte orders", func() {
+			type Samples struct {
+				Values []uint16 `d2b:"length:4,endian_alternate:big/little"`
+			}
+			var samples Samples
+			err := Decode(wire, binary.LittleEndian, &samples)
+			So(err, ShouldBeNil)
+			So(samples.Values, ShouldResemble, []uint16{1, 2, 3, 4})
+			bytes, err := Encode(samples, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should decode array elements with alternating byte orders", func() {
+			type Samples struct {
+				Values [4]uint16 `d2b:"endian_alternate:big/little"`
+			}
+			var samples Samples
+			err := Decode(wire, binary.BigEndian, &samples)
+			So(err, ShouldBeNil)
+			So(samples.Values, ShouldResemble, [4]uint16{1, 2, 3, 4})
+			bytes, err := Encode(samples, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error for bad byte orders", func() {
+			type Bad struct {
+				Values [4]uint16 `d2b:"endian_alternate:big"`
+			}
+			_, err := Encode(Bad{}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
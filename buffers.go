@@ -0,0 +1,56 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// NewBuffersDecoder returns decoder, which reads data from logically concatenated buffers (e.g. net.Buffers).
+// Bytes are copied only if some field is split between buffers
+func NewBuffersDecoder(buffers [][]byte, endian binary.ByteOrder) *Decoder {
+	var length int
+	for _, buffer := range buffers {
+		length += len(buffer)
+	}
+	return &Decoder{buffers: buffers, buffersLength: length, endian: endian}
+}
+
+// DecodeBuffers writes logically concatenated buffers to data
+func DecodeBuffers(buffers [][]byte, endian binary.ByteOrder, data interface{}) error {
+	return NewBuffersDecoder(buffers, endian).Decode(data)
+}
+
+// nextFromBuffers returns next n bytes of buffers and moves offset after them
+func (d *Decoder) nextFromBuffers(n int) ([]byte, error) {
+	left := d.buffersLength - d.offset
+	if n > left {
+		return nil, errors.Wrapf(io.ErrUnexpectedEOF, "need %d bytes, but only %d left", n, left)
+	}
+	pos := d.offset
+	d.offset += n
+	for i, buffer := range d.buffers {
+		if pos >= len(buffer) {
+			pos -= len(buffer)
+			continue
+		}
+		if pos+n <= len(buffer) {
+			return buffer[pos : pos+n], nil
+		}
+		result := make([]byte, 0, n)
+		for _, buffer := range d.buffers[i:] {
+			buffer = buffer[pos:]
+			pos = 0
+			if len(buffer) > n-len(result) {
+				buffer = buffer[:n-len(result)]
+			}
+			result = append(result, buffer...)
+			if len(result) == n {
+				break
+			}
+		}
+		return result, nil
+	}
+	return []byte{}, nil
+}
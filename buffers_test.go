@@ -0,0 +1,48 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeBuffers(t *testing.T) {
+	Convey("Test DecodeBuffers", t, func() {
+		type Struct struct {
+			A    uint16
+			B    uint32
+			Len  uint8
+			Name string `d2b:"length_from:Len"`
+			C    [3]uint8
+		}
+		expected := Struct{A: 1, B: 0x04030201, Len: 5, Name: "hello", C: [3]uint8{7, 8, 9}}
+		Convey("Should decode fields, which straddle buffers", func() {
+			buffers := net.Buffers{
+				{1, 0, 1, 2},
+				{},
+				{3, 4, 5, 'h', 'e'},
+				{'l'},
+				{'l', 'o', 7, 8, 9},
+			}
+			var result Struct
+			err := DecodeBuffers(buffers, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, expected)
+		})
+		Convey("Should return error if buffers are too short", func() {
+			var result Struct
+			err := DecodeBuffers([][]byte{{1, 0, 1}, {2, 3}}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should not copy bytes of fields inside one buffer", func() {
+			buffer := []byte{1, 2, 3, 4}
+			d := NewBuffersDecoder([][]byte{{0}, buffer}, binary.LittleEndian)
+			d.offset = 1
+			b, err := d.nextFromBuffers(4)
+			So(err, ShouldBeNil)
+			So(&b[0], ShouldEqual, &buffer[0])
+		})
+	})
+}
@@ -0,0 +1,40 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// isBulkNumber returns true if slice of t elements can be decoded with single read, i.e. t is fixed size number
+func isBulkNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// decodeNumbers decodes all elements of slice v of fixed size numbers from single read. Bytes are copied
+// to slice memory at once, if it's allowed by build and byte order matches the host one
+func (d *Decoder) decodeNumbers(v reflect.Value, endian binary.ByteOrder) error {
+	t := v.Type().Elem()
+	endian = typeEndian(t, endian)
+	size := int(t.Size())
+	if v.Len() > maxInt/size {
+		return errors.Errorf("too many elements %d", v.Len())
+	}
+	bytes, err := d.next(v.Len() * size)
+	if err != nil {
+		return err
+	}
+	if d.ForceUnsigned && isSigned(t.Kind()) || !copyNumbers(v, bytes, endian) {
+		for i := 0; i < v.Len(); i++ {
+			d.setNumber(v.Index(i), bytes[i*size:(i+1)*size], endian)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !d2b_unsafe
+// +build !d2b_unsafe
+
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+)
+
+// copyNumbers never copies bytes to slice memory without unsafe package. Build with d2b_unsafe tag to enable it
+func copyNumbers(v reflect.Value, bytes []byte, endian binary.ByteOrder) bool {
+	return false
+}
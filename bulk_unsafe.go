@@ -0,0 +1,32 @@
+//go:build d2b_unsafe
+// +build d2b_unsafe
+
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+	"unsafe"
+)
+
+// hostEndian is a byte order of numbers in memory
+var hostEndian = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// copyNumbers copies bytes of numbers to memory of slice v, if they're stored in host byte order.
+// It returns false if they aren't
+func copyNumbers(v reflect.Value, bytes []byte, endian binary.ByteOrder) bool {
+	if endian != hostEndian {
+		return false
+	}
+	var memory []byte
+	header := (*reflect.SliceHeader)(unsafe.Pointer(&memory))
+	header.Data, header.Len, header.Cap = v.Pointer(), len(bytes), len(bytes)
+	copy(memory, bytes)
+	return true
+}
@@ -0,0 +1,56 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// decodeBytePrefixed reads bytes count of declared width and decodes slice elements until that many bytes
+// are consumed. Elements shouldn't cross the end of region
+func (d *Decoder) decodeBytePrefixed(parent, v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	prefix, err := d.next(tags.BytePrefix)
+	if err != nil {
+		return err
+	}
+	length := readUint(prefix, endian)
+	err = d.checkAllocation(length, 1)
+	if err != nil {
+		return errors.Wrapf(err, "bytes count %d exceeds data length", length)
+	}
+	region, err := d.next(int(length))
+	if err != nil {
+		return err
+	}
+	sub := *d
+	sub.bytes, sub.buffers, sub.reader, sub.offset = region, nil, nil, 0
+	// region of reader decoder may be its scratch buffer
+	sub.ZeroCopyStrings = d.ZeroCopyStrings && d.reader == nil
+	if tags.RLE != 0 {
+		return errors.Wrapf(sub.decodeRLE(parent, v, tags, endian), "can't decode %d-byte region", length)
+	}
+	return errors.Wrapf(sub.decodeRest(v, tags, endian), "can't decode %d-byte region", length)
+}
+
+// bytePrefixedToBytes writes bytes count of declared width, followed by slice elements
+func (e *Encoder) bytePrefixedToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	start := buffer.Len()
+	buffer.Write(make([]byte, ft.BytePrefix))
+	var err error
+	if ft.RLE != 0 {
+		err = e.rleToBytes(parent, v, ft, buffer, endian)
+	} else {
+		err = e.restToBytes(v, ft, buffer, endian)
+	}
+	if err != nil {
+		return err
+	}
+	length := buffer.Len() - start - ft.BytePrefix
+	if ft.BytePrefix < 8 && uint64(length) >= uint64(1)<<uint(8*ft.BytePrefix) {
+		return errors.Errorf("bytes count %d doesn't fit %d-byte prefix", length, ft.BytePrefix)
+	}
+	putUint(buffer.Bytes()[start:start+ft.BytePrefix], endian, uint64(length))
+	return nil
+}
@@ -0,0 +1,70 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testVarint struct {
+	V uint64 `d2b:"fn:V"`
+}
+
+// DecodeV decodes V as LEB128
+func (v *testVarint) DecodeV(bytes []byte, endian binary.ByteOrder) (int, error) {
+	value, n := binary.Uvarint(bytes)
+	if n <= 0 {
+		return 0, errors.New("bad varint")
+	}
+	v.V = value
+	return n, nil
+}
+
+// EncodeV encodes V as LEB128
+func (v testVarint) EncodeV(endian binary.ByteOrder) ([]byte, error) {
+	b := make([]byte, binary.MaxVarintLen64)
+	return b[:binary.PutUvarint(b, v.V)], nil
+}
+
+func TestBytePrefix(t *testing.T) {
+	Convey("Test slices, prefixed with bytes count", t, func() {
+		type Message struct {
+			Values []testVarint `d2b:"byte_prefix:u16"`
+			Tail   uint8
+		}
+		data := Message{Values: []testVarint{{1}, {300}, {127}, {16384}}, Tail: 9}
+		wire := []byte{0, 7, 0x01, 0xac, 0x02, 0x7f, 0x80, 0x80, 0x01, 9}
+		Convey("Should decode self-delimiting elements of region", func() {
+			var result Message
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should encode bytes count of elements", func() {
+			bytes, err := Encode(data, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error if elements don't align to region", func() {
+			var result Message
+			err := Decode([]byte{0, 2, 0x01, 0xac, 0x02, 9}, binary.BigEndian, &result)
+			So(err, ShouldNotBeNil)
+			err = Decode([]byte{0, 9, 0x01, 9}, binary.BigEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should limit bytes count, read from stream, with MaxStringLen", func() {
+			type Blob struct {
+				Data []uint16 `d2b:"byte_prefix:u32"`
+			}
+			var result Blob
+			decoder := NewReaderDecoder(bytes.NewReader([]byte{0x7f, 0xff, 0xff, 0xff, 1, 2}), binary.BigEndian)
+			So(decoder.Decode(&result), ShouldNotBeNil)
+			decoder = NewReaderDecoder(bytes.NewReader([]byte{0, 0, 0, 2, 1, 2}), binary.BigEndian)
+			So(decoder.Decode(&result), ShouldBeNil)
+			So(result.Data, ShouldResemble, []uint16{0x0102})
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// DecodeColumnar decodes elements, which follow each other in bytes. Layout of every element is selected by its
+// code from codes slice of integers: element is decoded as a value of types[code] type.
+// It returns pointers to elements. Bytes should contain exactly the elements
+func DecodeColumnar(bytes []byte, endian binary.ByteOrder, codes interface{}, types map[uint64]interface{}) ([]interface{}, error) {
+	codesValue := reflect.ValueOf(codes)
+	if codesValue.Kind() != reflect.Slice && codesValue.Kind() != reflect.Array || !isInteger(codesValue.Type().Elem().Kind()) {
+		return nil, errors.Errorf("codes should be slice of integers, not %T", codes)
+	}
+	d := NewDecoder(bytes, endian)
+	result := make([]interface{}, codesValue.Len())
+	for i := range result {
+		code := codesValue.Index(i)
+		var id uint64
+		if isSigned(code.Kind()) {
+			id = uint64(code.Int())
+		} else {
+			id = code.Uint()
+		}
+		template, ok := types[id]
+		t := reflect.TypeOf(template)
+		if !ok || t == nil {
+			return nil, errors.Errorf("type of element %d with code %d is not declared", i, id)
+		}
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		element := reflect.New(t)
+		err := d.Decode(element.Interface())
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't decode element %d", i)
+		}
+		result[i] = element.Interface()
+	}
+	if d.Remaining() != 0 {
+		return nil, errors.Errorf("%d bytes left after the last element", d.Remaining())
+	}
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeColumnar(t *testing.T) {
+	Convey("Test DecodeColumnar", t, func() {
+		type IntCell struct {
+			Value int32
+		}
+		type TextCell struct {
+			Len  uint8
+			Text string `d2b:"length_from:Len"`
+		}
+		types := map[uint64]interface{}{1: IntCell{}, 2: TextCell{}}
+		data := []byte{
+			0xff, 0xff, 0xff, 0xff,
+			2, 'h', 'i',
+			7, 0, 0, 0,
+		}
+		Convey("Should decode elements with layouts, selected by codes", func() {
+			cells, err := DecodeColumnar(data, binary.LittleEndian, []uint8{1, 2, 1}, types)
+			So(err, ShouldBeNil)
+			So(cells, ShouldResemble, []interface{}{&IntCell{Value: -1}, &TextCell{Len: 2, Text: "hi"}, &IntCell{Value: 7}})
+		})
+		Convey("Should return error for unknown code", func() {
+			_, err := DecodeColumnar(data, binary.LittleEndian, []uint8{1, 3, 1}, types)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if bytes are left", func() {
+			_, err := DecodeColumnar(data, binary.LittleEndian, []int{1, 2}, types)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
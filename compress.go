@@ -0,0 +1,183 @@
+package d2b
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Compressor compresses and decompresses fields with compress option
+type Compressor struct {
+	// NewReader returns reader of data, decompressed from r
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	// NewWriter returns writer, which writes compressed data to w. Data is flushed on Close
+	NewWriter func(w io.Writer) io.WriteCloser
+}
+
+var compressorsMx sync.RWMutex
+var compressors = map[string]Compressor{
+	"zlib": {
+		NewReader: zlib.NewReader,
+		NewWriter: func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	},
+	"gzip": {
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		NewWriter: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+	},
+}
+
+// RegisterCompressor registers compressor, which is used by fields with compress:name option.
+// zlib and gzip compressors are registered by default
+func RegisterCompressor(name string, compressor Compressor) {
+	compressorsMx.Lock()
+	defer compressorsMx.Unlock()
+	compressors[name] = compressor
+}
+
+func getCompressor(name string) (Compressor, error) {
+	compressorsMx.RLock()
+	defer compressorsMx.RUnlock()
+	compressor, ok := compressors[name]
+	if !ok {
+		return Compressor{}, errors.Errorf("compressor %q is not registered", name)
+	}
+	return compressor, nil
+}
+
+// checkCompressed checks, that compressed field declares count of compressed bytes with byte_prefix
+// or length options
+func checkCompressed(tag *structFieldTag) error {
+	if tag.BytePrefix == 0 && !tag.hasLength() {
+		return errors.New("count of compressed bytes should be declared with byte_prefix or length options")
+	}
+	return nil
+}
+
+// decodeCompressed reads compressed bytes and decompresses them. []byte field is set to decompressed bytes,
+// fields of other types are decoded from them. Decompressed data is limited with Decoder.MaxStringLen
+func (d *Decoder) decodeCompressed(parent, v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	compressor, err := getCompressor(tags.Compress)
+	if err != nil {
+		return err
+	}
+	var length uint64
+	if tags.BytePrefix != 0 {
+		prefix, err := d.next(tags.BytePrefix)
+		if err != nil {
+			return err
+		}
+		length = readUint(prefix, endian)
+	} else {
+		l, err := tags.length(parent)
+		if err != nil {
+			return err
+		}
+		length = uint64(l)
+	}
+	err = d.checkAllocation(length, 1)
+	if err != nil {
+		return errors.Wrapf(err, "compressed bytes count %d exceeds data length", length)
+	}
+	compressed, err := d.next(int(length))
+	if err != nil {
+		return err
+	}
+	reader, err := compressor.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return errors.Wrapf(err, "can't decompress %s data", tags.Compress)
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(reader, int64(d.maxStringLen())+1))
+	if err != nil {
+		return errors.Wrapf(err, "can't decompress %s data", tags.Compress)
+	}
+	if len(data) > d.maxStringLen() {
+		return errors.Errorf("decompressed data exceeds limit %d", d.maxStringLen())
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		result := reflect.MakeSlice(v.Type(), len(data), len(data))
+		reflect.Copy(result, reflect.ValueOf(data))
+		v.Set(result)
+		return nil
+	}
+	sub := *d
+	sub.bytes, sub.buffers, sub.reader, sub.offset = data, nil, nil, 0
+	err = sub.decodeValue(v, endian)
+	if err != nil {
+		return errors.Wrapf(err, "can't decode decompressed %v", v.Type())
+	}
+	if sub.offset != len(data) {
+		return errors.Errorf("%d decompressed bytes are left after %v", len(data)-sub.offset, v.Type())
+	}
+	return nil
+}
+
+// compressedToBytes compresses field bytes and writes them, preceded by their count if byte_prefix option
+// is set. Count of compressed bytes should match length option otherwise
+func (e *Encoder) compressedToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	compressor, err := getCompressor(ft.Compress)
+	if err != nil {
+		return err
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+			continue
+		}
+		v = v.Elem()
+	}
+	var data []byte
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		data = make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(data), v)
+	} else {
+		dataBuffer := bytes.NewBuffer(nil)
+		err = e.valueToBytes(v, dataBuffer, endian)
+		if err != nil {
+			return err
+		}
+		data = dataBuffer.Bytes()
+	}
+	compressed := bytes.NewBuffer(nil)
+	writer := compressor.NewWriter(compressed)
+	_, err = writer.Write(data)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "can't compress %s data", ft.Compress)
+	}
+	length := compressed.Len()
+	if ft.BytePrefix == 0 {
+		declared, err := ft.length(parent)
+		if err != nil {
+			return err
+		}
+		if declared != length {
+			return errors.Errorf("%d compressed bytes don't match declared length %d", length, declared)
+		}
+		buffer.Write(compressed.Bytes())
+		return nil
+	}
+	if ft.BytePrefix < 8 && uint64(length) >= uint64(1)<<uint(8*ft.BytePrefix) {
+		return errors.Errorf("compressed bytes count %d doesn't fit %d-byte prefix", length, ft.BytePrefix)
+	}
+	prefix := make([]byte, ft.BytePrefix)
+	putUint(prefix, endian, uint64(length))
+	buffer.Write(prefix)
+	buffer.Write(compressed.Bytes())
+	return nil
+}
@@ -0,0 +1,80 @@
+package d2b
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCompress(t *testing.T) {
+	Convey("Test compress option", t, func() {
+		type Inner struct {
+			ID     uint32
+			Values [4]uint16
+		}
+		type Packet struct {
+			Kind  uint8
+			Inner Inner  `d2b:"compress:zlib,byte_prefix:u16"`
+			Blob  []byte `d2b:"compress:gzip,byte_prefix:u32"`
+			Tail  uint8
+		}
+		packet := Packet{
+			Kind:  1,
+			Inner: Inner{ID: 7, Values: [4]uint16{1, 2, 3, 4}},
+			Blob:  bytes.Repeat([]byte("abc"), 100),
+			Tail:  0xff,
+		}
+		Convey("Should round-trip compressed sub-struct and bytes", func() {
+			data, err := Encode(packet, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(data[0], ShouldEqual, 1)
+			So(data[len(data)-1], ShouldEqual, 0xff)
+
+			length := int(binary.LittleEndian.Uint16(data[1:]))
+			reader, err := zlib.NewReader(bytes.NewReader(data[3 : 3+length]))
+			So(err, ShouldBeNil)
+			var inner Inner
+			So(NewReaderDecoder(reader, binary.LittleEndian).Decode(&inner), ShouldBeNil)
+			So(inner, ShouldResemble, packet.Inner)
+
+			var decoded Packet
+			err = Decode(data, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, packet)
+		})
+		Convey("Should return error if decompressed data exceeds limit", func() {
+			data, err := Encode(packet, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			decoder := NewDecoder(data, binary.LittleEndian)
+			decoder.MaxStringLen = 100
+			So(decoder.Decode(&Packet{}), ShouldNotBeNil)
+		})
+		Convey("Should return error for corrupted data", func() {
+			var decoded Packet
+			err := Decode([]byte{1, 2, 0, 1, 2}, binary.LittleEndian, &decoded)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should limit compressed bytes count, read from stream, with MaxStringLen", func() {
+			type Blob struct {
+				Data []byte `d2b:"compress:zlib,byte_prefix:u32"`
+			}
+			decoder := NewReaderDecoder(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0x7f, 1}), binary.LittleEndian)
+			So(decoder.Decode(&Blob{}), ShouldNotBeNil)
+			data, err := Encode(Blob{Data: []byte("abc")}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			var decoded Blob
+			So(NewReaderDecoder(bytes.NewReader(data), binary.LittleEndian).Decode(&decoded), ShouldBeNil)
+			So(decoded.Data, ShouldResemble, []byte("abc"))
+		})
+		Convey("Should return error without compressed bytes count", func() {
+			type Bad struct {
+				Blob []byte `d2b:"compress:zlib"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
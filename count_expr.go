@@ -0,0 +1,37 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// decodeCountExpr decodes slice, which elements count is a value of count_expr expression.
+// Expression's total is a count of bytes from the beginning of decoded data to its end
+func (d *Decoder) decodeCountExpr(parent, v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	if d.reader != nil {
+		return errors.New("count_expr can't be decoded from reader")
+	}
+	count, err := tags.countExpr.eval(parent, d.length()-d.frameStart)
+	if err != nil {
+		return errors.Wrapf(err, "can't evaluate %q", tags.CountExpr)
+	}
+	// every element takes at least one byte
+	if count < 0 || count > int64(d.length()-d.offset) {
+		return errors.Errorf("elements count %d is out of data", count)
+	}
+	err = tags.checkCount(int(count))
+	if err != nil {
+		return err
+	}
+	result := d.makeSlice(v.Type(), int(count))
+	for i := 0; i < int(count); i++ {
+		err = d.decodeElement(result.Index(i), tags, endian)
+		if err != nil {
+			return err
+		}
+	}
+	v.Set(result)
+	return nil
+}
@@ -0,0 +1,53 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCountExpr(t *testing.T) {
+	Convey("Test slices with count_expr", t, func() {
+		type Frame struct {
+			Type    uint32
+			Time    uint32
+			Samples []int32 `d2b:"count_expr:(total - 8) / 4"`
+		}
+		Convey("Should derive elements count from frame length", func() {
+			wire := []byte{0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0xff, 0xff, 0xff, 0xfe, 0, 0, 0, 5}
+			var result Frame
+			err := DecodeLimited(append(wire, 9, 9, 9, 9), binary.BigEndian, &result, len(wire))
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Frame{Type: 1, Time: 2, Samples: []int32{3, -2, 5}})
+			bytes, err := Encode(result, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should use values of previous fields", func() {
+			type Packet struct {
+				Groups uint8
+				Values []uint8 `d2b:"count_expr:Groups * 2 + 1"`
+			}
+			var result Packet
+			err := Decode([]byte{2, 1, 2, 3, 4, 5}, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Values, ShouldResemble, []uint8{1, 2, 3, 4, 5})
+			So(Decode([]byte{3, 1, 2}, binary.BigEndian, &result), ShouldNotBeNil)
+		})
+		Convey("Should return error for bad expressions", func() {
+			type Unknown struct {
+				Values []uint8 `d2b:"count_expr:size - 1"`
+			}
+			So(Decode([]byte{1}, binary.BigEndian, &Unknown{}), ShouldNotBeNil)
+			type Unbalanced struct {
+				Values []uint8 `d2b:"count_expr:(total - 1"`
+			}
+			So(Decode([]byte{1}, binary.BigEndian, &Unbalanced{}), ShouldNotBeNil)
+			type Zero struct {
+				Values []uint8 `d2b:"count_expr:total / 0"`
+			}
+			So(Decode([]byte{1}, binary.BigEndian, &Zero{}), ShouldNotBeNil)
+		})
+	})
+}
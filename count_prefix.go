@@ -0,0 +1,90 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// decodeCountPrefixed reads elements count of declared width and decodes that many slice elements.
+// Bytes of []byte slices are copied at once
+func (d *Decoder) decodeCountPrefixed(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	prefix, err := d.next(tags.CountPrefix)
+	if err != nil {
+		return err
+	}
+	count := readUint(prefix, endian)
+	err = d.checkAllocation(count, 0)
+	if err != nil {
+		return err
+	}
+	// every element takes at least one byte, except of nibbles
+	needed := count
+	if tags.Nibbles {
+		needed = (count + 1) / 2
+	}
+	err = d.checkAllocation(needed, 1)
+	if err != nil {
+		return errors.Wrapf(err, "elements count %d exceeds data length", count)
+	}
+	err = tags.checkCount(int(count))
+	if err != nil {
+		return err
+	}
+	if tags.Nibbles {
+		return d.decodeNibbles(v, int(count))
+	}
+	t := v.Type()
+	if t.Elem().Kind() == reflect.Uint8 {
+		b, err := d.next(int(count))
+		if err != nil {
+			return err
+		}
+		result := d.makeSlice(t, int(count))
+		reflect.Copy(result, reflect.ValueOf(b))
+		v.Set(result)
+		return nil
+	}
+	result := d.makeSlice(t, int(count))
+	for i := 0; i < int(count); i++ {
+		err = d.decodeElement(result.Index(i), tags, endian)
+		if err != nil {
+			return err
+		}
+	}
+	v.Set(result)
+	return nil
+}
+
+// countPrefixedToBytes writes elements count of declared width, followed by slice elements
+func (e *Encoder) countPrefixedToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	count := v.Len()
+	err := ft.checkCount(count)
+	if err != nil {
+		return err
+	}
+	if ft.CountPrefix < 8 && uint64(count) >= uint64(1)<<uint(8*ft.CountPrefix) {
+		return errors.Errorf("elements count %d doesn't fit %d-byte prefix", count, ft.CountPrefix)
+	}
+	prefix := make([]byte, ft.CountPrefix)
+	putUint(prefix, endian, uint64(count))
+	buffer.Write(prefix)
+	if ft.Nibbles {
+		return e.nibblesToBytes(v, count, buffer)
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, count)
+		reflect.Copy(reflect.ValueOf(b), v)
+		buffer.Write(b)
+		return nil
+	}
+	for i := 0; i < count; i++ {
+		err = e.elementToBytes(v.Index(i), ft, buffer, endian)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
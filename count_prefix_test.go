@@ -0,0 +1,90 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCountPrefix(t *testing.T) {
+	Convey("Test count prefixed slices", t, func() {
+		type Packet struct {
+			Payload []byte   `d2b:"count_prefix:u16"`
+			Empty   []byte   `d2b:"count_prefix:u16"`
+			Words   []uint16 `d2b:"count_prefix:u8"`
+		}
+		data := Packet{Payload: []byte{1, 2, 3}, Empty: []byte{}, Words: []uint16{0x0102}}
+		wire := []byte{0, 3, 1, 2, 3, 0, 0, 1, 1, 2}
+		Convey("Should decode length prefixed blobs", func() {
+			var result Packet
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+			wire[2] = 9
+			So(result.Payload[0], ShouldEqual, 1)
+		})
+		Convey("Should encode length prefixed blobs", func() {
+			bytes, err := Encode(data, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error if count exceeds data length", func() {
+			var result Packet
+			err := Decode([]byte{0xff, 0xff, 1, 2}, binary.BigEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should limit count, read from stream, with MaxStringLen", func() {
+			type Big struct {
+				Values []uint64 `d2b:"count_prefix:u32"`
+				Huge   []byte   `d2b:"count_prefix:u64"`
+			}
+			var result Big
+			decoder := NewReaderDecoder(bytes.NewReader([]byte{0x7f, 0xff, 0xff, 0xff, 1, 2}), binary.BigEndian)
+			So(decoder.Decode(&result), ShouldNotBeNil)
+			decoder = NewReaderDecoder(bytes.NewReader([]byte{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), binary.BigEndian)
+			So(decoder.Decode(&result), ShouldNotBeNil)
+			decoder = NewReaderDecoder(bytes.NewReader([]byte{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1, 7}), binary.BigEndian)
+			So(decoder.Decode(&result), ShouldBeNil)
+			So(result, ShouldResemble, Big{Values: []uint64{1}, Huge: []byte{7}})
+		})
+		Convey("Should return error if count doesn't fit prefix", func() {
+			_, err := Encode(Packet{Words: make([]uint16, 256)}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should decode and encode interfaces with type id before every element", func() {
+			type Shapes struct {
+				Items []testShape `d2b:"count_prefix:u16,typeid:u8"`
+			}
+			wire := []byte{0, 3, 1, 0, 0, 0, 2, 2, 0, 3, 0, 4, 1, 0, 0, 0, 5}
+			var result Shapes
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Items, ShouldResemble, []testShape{testSquare{Side: 2}, &testRect{W: 3, H: 4}, testSquare{Side: 5}})
+			bytes, err := Encode(result, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+			So(Validate(result), ShouldBeNil)
+
+			err = Decode([]byte{0, 1, 9, 0}, binary.BigEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func BenchmarkDecodeCountPrefix(b *testing.B) {
+	type Packet struct {
+		Payload []byte `d2b:"count_prefix:u16"`
+	}
+	wire := make([]byte, 2+1024)
+	binary.BigEndian.PutUint16(wire, 1024)
+	b.SetBytes(int64(len(wire)))
+	for i := 0; i < b.N; i++ {
+		var result Packet
+		err := Decode(wire, binary.BigEndian, &result)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
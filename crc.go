@@ -0,0 +1,120 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// checkCRCType checks, that field with crc32 option is uint32
+func checkCRCType(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Uint32 {
+		return errors.Errorf("crc32 field should be uint32, not %v", t)
+	}
+	return nil
+}
+
+// resolveCRCRange checks, that crc_range refers to two of the previous fields: the first and the last
+// field, covered by checksum
+func resolveCRCRange(structType reflect.Type, previous []int, tag *structFieldTag) error {
+	names := strings.Split(tag.CRCRange, ":")
+	if len(names) != 2 {
+		return errors.Errorf("bad crc_range %q, should be StartField:EndField", tag.CRCRange)
+	}
+	from, to := -1, -1
+	for position, i := range previous {
+		name := structType.Field(i).Name
+		if name == names[0] {
+			from = position
+		}
+		if name == names[1] {
+			to = position
+		}
+	}
+	if from == -1 || to == -1 {
+		return errors.Errorf("crc_range fields %s and %s should be declared before", names[0], names[1])
+	}
+	if from > to {
+		return errors.Errorf("crc_range field %s should be declared after %s", names[1], names[0])
+	}
+	tag.crcFromIndex, tag.crcToIndex = previous[from], previous[to]
+	return nil
+}
+
+// resolveCRCOver checks, that crc_over refers to one of the following fields
+func resolveCRCOver(structType reflect.Type, following []int, tag *structFieldTag) error {
+	for _, i := range following {
+		if structType.Field(i).Name == tag.CRCOver {
+			tag.crcOverIndex = i
+			return nil
+		}
+	}
+	return errors.Errorf("crc_over field %s should be declared after", tag.CRCOver)
+}
+
+// crcOverField returns index of crc32 field, which covers field i with crc_over option, or -1 if there
+// is no such field
+func crcOverField(tags []*structFieldTag, i int) int {
+	for j, tag := range tags {
+		if tag.CRCOver != "" && tag.crcOverIndex == i {
+			return j
+		}
+	}
+	return -1
+}
+
+// removeIndex removes index i from indexes
+func removeIndex(indexes []int, i int) []int {
+	for k, index := range indexes {
+		if index == i {
+			return append(indexes[:k], indexes[k+1:]...)
+		}
+	}
+	return indexes
+}
+
+// crcRange returns offsets of bytes, covered by checksum of field with crc32 option. ranges contain
+// offsets of previous struct fields. By default checksum covers struct bytes before the field
+func (t *structFieldTag) crcRange(ranges [][2]int, structStart, fieldStart int) (int, int) {
+	if t.CRCRange == "" {
+		return structStart, fieldStart
+	}
+	return ranges[t.crcFromIndex][0], ranges[t.crcToIndex][1]
+}
+
+// checkCRC checks, that value of crc32 field v matches checksum of bytes at [start:end)
+func (d *Decoder) checkCRC(v reflect.Value, start, end int) error {
+	if d.reader != nil {
+		return errors.New("checksum can't be checked in reader")
+	}
+	offset := d.offset
+	d.offset = start
+	b, err := d.next(end - start)
+	d.offset = offset
+	if err != nil {
+		return err
+	}
+	if sum, value := crc32.ChecksumIEEE(b), uintValue(v); uint64(sum) != value {
+		return errors.Errorf("crc32 field contains %#08x, but checksum is %#08x", value, sum)
+	}
+	return nil
+}
+
+// patchCRC overwrites 4 bytes at offset with CRC-32 checksum of buffer bytes at [start:end)
+func patchCRC(buffer *bytes.Buffer, offset, start, end int, endian binary.ByteOrder) {
+	endian.PutUint32(buffer.Bytes()[offset:], crc32.ChecksumIEEE(buffer.Bytes()[start:end]))
+}
+
+// crcToBytes writes CRC-32 checksum of buffer bytes at [start:end)
+func crcToBytes(buffer *bytes.Buffer, start, end int, endian binary.ByteOrder) {
+	b := make([]byte, 4)
+	endian.PutUint32(b, crc32.ChecksumIEEE(buffer.Bytes()[start:end]))
+	buffer.Write(b)
+}
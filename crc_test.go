@@ -0,0 +1,125 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCRC(t *testing.T) {
+	Convey("Test crc32 fields", t, func() {
+		type Frame struct {
+			Magic  uint16
+			Seq    uint8
+			Kind   uint8
+			Value  uint32
+			CRC    uint32 `d2b:"crc32:true,crc_range:Kind:Value"`
+			Footer uint8
+		}
+		body := []byte{7, 0, 0, 1, 0}
+		wire := append([]byte{0xca, 0xfe, 1}, body...)
+		wire = append(wire, 0, 0, 0, 0, 0xff)
+		binary.BigEndian.PutUint32(wire[8:], crc32.ChecksumIEEE(body))
+		data := Frame{Magic: 0xcafe, Seq: 1, Kind: 7, Value: 256, CRC: crc32.ChecksumIEEE(body), Footer: 0xff}
+		Convey("Should encode checksum of body, excluding header", func() {
+			bytes, err := Encode(Frame{Magic: 0xcafe, Seq: 1, Kind: 7, Value: 256, Footer: 0xff}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should decode and check checksum of body", func() {
+			var result Frame
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+
+			changed := append([]byte{}, wire...)
+			changed[1] = 0
+			err = Decode(changed, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+
+			changed[5] = 1
+			err = Decode(changed, binary.BigEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should cover bytes before field by default", func() {
+			type Message struct {
+				A   uint16
+				B   uint8
+				CRC uint32 `d2b:"crc32:true,endian:little"`
+			}
+			bytes, err := Encode(Message{A: 1, B: 2}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(binary.LittleEndian.Uint32(bytes[3:]), ShouldEqual, crc32.ChecksumIEEE([]byte{0, 1, 2}))
+			var result Message
+			So(Decode(bytes, binary.BigEndian, &result), ShouldBeNil)
+		})
+		Convey("Should return error for bad crc_range", func() {
+			type Bad struct {
+				A   uint8
+				B   uint8
+				CRC uint32 `d2b:"crc32:true,crc_range:B:A"`
+			}
+			So(Decode(make([]byte, 6), binary.BigEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestCRCOver(t *testing.T) {
+	Convey("Test crc_over option", t, func() {
+		type Frame struct {
+			CRC     uint32 `d2b:"crc32:true,crc_over:Payload"`
+			Kind    uint8
+			Payload []byte `d2b:"byte_prefix:u8"`
+			Footer  uint8
+		}
+		payload := []byte("hello")
+		wire := append([]byte{0, 0, 0, 0, 1}, 5)
+		wire = append(wire, payload...)
+		wire = append(wire, 0xff)
+		binary.BigEndian.PutUint32(wire, crc32.ChecksumIEEE(append([]byte{5}, payload...)))
+		Convey("Should encode leading checksum of following payload", func() {
+			bytes, err := Encode(Frame{Kind: 1, Payload: payload, Footer: 0xff}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should check leading checksum after payload is decoded", func() {
+			var frame Frame
+			err := Decode(wire, binary.BigEndian, &frame)
+			So(err, ShouldBeNil)
+			So(frame.Payload, ShouldResemble, payload)
+
+			changed := append([]byte{}, wire...)
+			changed[4] = 2
+			So(Decode(changed, binary.BigEndian, &frame), ShouldBeNil)
+			changed[7] = 'a'
+			So(Decode(changed, binary.BigEndian, &frame), ShouldNotBeNil)
+		})
+		Convey("Should return error if covered field isn't decoded", func() {
+			type Versioned struct {
+				CRC     uint32  `d2b:"crc32:true,crc_over:Payload"`
+				Payload [2]byte `d2b:"since:2"`
+			}
+			decoder := NewDecoder([]byte{1, 2, 3, 4}, binary.BigEndian)
+			decoder.Version = 1
+			So(decoder.Decode(&Versioned{}), ShouldNotBeNil)
+
+			type Omitted struct {
+				CRC     uint32  `d2b:"crc32:true,crc_over:Payload"`
+				Payload [2]byte `d2b:"omit_empty:true"`
+			}
+			decoder = NewDecoder([]byte{1, 2, 3, 4}, binary.BigEndian)
+			decoder.AllowTruncation = true
+			So(decoder.Decode(&Omitted{}), ShouldNotBeNil)
+		})
+		Convey("Should return error if covered field isn't declared after", func() {
+			type Bad struct {
+				Payload [2]byte
+				CRC     uint32 `d2b:"crc32:true,crc_over:Payload"`
+			}
+			_, err := Encode(Bad{}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
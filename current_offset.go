@@ -0,0 +1,33 @@
+package d2b
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// checkCurrentOffsetType checks, that field with current_offset option is integer
+func checkCurrentOffsetType(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !isInteger(t.Kind()) {
+		return errors.Errorf("current offset field should be integer, not %v", t)
+	}
+	return nil
+}
+
+// setCurrentOffset sets decoder's offset to current_offset field v. Field takes no bytes
+func (d *Decoder) setCurrentOffset(v reflect.Value) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if isSigned(v.Kind()) {
+		v.SetInt(int64(d.offset))
+	} else {
+		v.SetUint(uint64(d.offset))
+	}
+}
@@ -0,0 +1,45 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCurrentOffset(t *testing.T) {
+	Convey("Test current_offset fields", t, func() {
+		type Entry struct {
+			At   int64 `d2b:"current_offset:true"`
+			Kind uint8
+			Len  uint8
+			Data []byte `d2b:"length_from:Len"`
+		}
+		type File struct {
+			Magic   uint16
+			Entries [2]Entry
+			End     *uint32 `d2b:"current_offset:true"`
+		}
+		wire := []byte{0xca, 0xfe, 1, 2, 'a', 'b', 2, 0}
+		Convey("Should set absolute offsets of fields without consuming bytes", func() {
+			var result File
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Entries[0], ShouldResemble, Entry{At: 2, Kind: 1, Len: 2, Data: []byte("ab")})
+			So(result.Entries[1], ShouldResemble, Entry{At: 6, Kind: 2})
+			So(*result.End, ShouldEqual, 8)
+		})
+		Convey("Should count offset from the beginning of buffer", func() {
+			decoder := NewDecoder(append([]byte{0, 0}, wire...), binary.BigEndian)
+			So(decoder.Seek(2), ShouldBeNil)
+			var result File
+			So(decoder.Decode(&result), ShouldBeNil)
+			So(result.Entries[0].At, ShouldEqual, 4)
+		})
+		Convey("Should write no bytes for current_offset fields", func() {
+			bytes, err := Encode(File{Magic: 0xcafe, Entries: [2]Entry{{At: 100, Kind: 1}, {Kind: 2}}}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0xca, 0xfe, 1, 0, 2, 0})
+		})
+	})
+}
@@ -0,0 +1,278 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Limits of IEEE 754 decimal64 numbers, which are coefficient * 10^exponent
+const (
+	decimal64Digits = 16
+	decimal64Bias   = 398
+	decimal64MaxExp = 369
+)
+
+var bigFloatType = reflect.TypeOf(big.Float{})
+
+// declets maps densely packed decimal declets to their 3-digit values, and digits maps values to
+// canonical declets
+var declets, digits = dpdTables()
+
+func dpdTables() (declets [1024]int, digits [1000]int) {
+	for i := range digits {
+		digits[i] = -1
+	}
+	for declet := range declets {
+		value := decodeDeclet(declet)
+		declets[declet] = value
+		// declets are iterated in ascending order, so canonical declet with zero don't care bits is the first one
+		if digits[value] == -1 {
+			digits[value] = declet
+		}
+	}
+	return declets, digits
+}
+
+// decodeDeclet returns 3-digit value of densely packed decimal declet
+func decodeDeclet(declet int) int {
+	bit := func(i uint) int { return declet >> i & 1 }
+	abc, def, ghi := declet>>7, declet>>4&7, declet&7
+	if bit(3) == 0 {
+		return abc*100 + def*10 + ghi
+	}
+	c, f, i := bit(7), bit(4), bit(0)
+	switch declet >> 1 & 3 {
+	case 0:
+		return abc*100 + def*10 + 8 + i
+	case 1:
+		return abc*100 + (8+f)*10 + (declet>>5&3)<<1 + i
+	case 2:
+		return (8+c)*100 + def*10 + (declet>>8&3)<<1 + i
+	}
+	switch declet >> 5 & 3 {
+	case 0:
+		return (8+c)*100 + (8+f)*10 + (declet>>8&3)<<1 + i
+	case 1:
+		return (8+c)*100 + (declet>>8&3<<1+f)*10 + 8 + i
+	case 2:
+		return abc*100 + (8+f)*10 + 8 + i
+	}
+	return (8+c)*100 + (8+f)*10 + 8 + i
+}
+
+// checkDecimal checks, that field with decimal option is string or big.Float, and width is supported
+func checkDecimal(t reflect.Type, bits int) error {
+	if bits != 64 {
+		return errors.Errorf("unsupported decimal width %d", bits)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String && t != bigFloatType {
+		return errors.Errorf("decimal field should be string or big.Float, not %v", t)
+	}
+	return nil
+}
+
+// decodeDecimal decodes IEEE 754 decimal64 number in densely packed decimal encoding. String fields
+// get its scientific string, e.g. "1.50", "-1.5E+10", "Infinity" or "NaN"
+func (d *Decoder) decodeDecimal(v reflect.Value, endian binary.ByteOrder) error {
+	b, err := d.next(8)
+	if err != nil {
+		return err
+	}
+	s := decimal64String(endian.Uint64(b))
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(s)
+		return nil
+	}
+	if s == "NaN" {
+		return errors.New("NaN can't be stored in big.Float")
+	}
+	f, _, err := big.ParseFloat(strings.TrimSuffix(s, "inity"), 10, 64, big.ToNearestEven)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(*f))
+	return nil
+}
+
+// decimalToBytes writes string or big.Float value as IEEE 754 decimal64 number in densely packed decimal
+// encoding. Nil pointers are written as zero
+func decimalToBytes(v reflect.Value, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+			continue
+		}
+		v = v.Elem()
+	}
+	var s string
+	if v.Kind() == reflect.String {
+		s = v.String()
+	} else {
+		f := v.Interface().(big.Float)
+		s = bigFloatDecimalString(&f)
+	}
+	bits, err := parseDecimal64(s)
+	if err != nil {
+		return err
+	}
+	b := make([]byte, 8)
+	endian.PutUint64(b, bits)
+	buffer.Write(b)
+	return nil
+}
+
+// decimal64String returns scientific string of decimal64 number, like IEEE 754 to-scientific-string
+func decimal64String(bits uint64) string {
+	sign := ""
+	if bits>>63 != 0 {
+		sign = "-"
+	}
+	combination := bits >> 58 & 0x1f
+	if combination == 0x1f {
+		return "NaN"
+	}
+	if combination == 0x1e {
+		return sign + "Infinity"
+	}
+	var exponent, msd uint64
+	if combination>>3 == 3 {
+		exponent, msd = combination>>1&3, 8+combination&1
+	} else {
+		exponent, msd = combination>>3, combination&7
+	}
+	exponent = exponent<<8 | bits>>50&0xff
+	coefficient := msd
+	for i := 4; i >= 0; i-- {
+		coefficient = coefficient*1000 + uint64(declets[bits>>(uint(i)*10)&0x3ff])
+	}
+	return sign + scientificString(strconv.FormatUint(coefficient, 10), int(exponent)-decimal64Bias)
+}
+
+// scientificString returns string of coefficient * 10^exponent. Plain notation is used if exponent isn't
+// positive and value isn't too small
+func scientificString(coefficient string, exponent int) string {
+	adjusted := exponent + len(coefficient) - 1
+	if exponent <= 0 && adjusted >= -6 {
+		if exponent == 0 {
+			return coefficient
+		}
+		point := len(coefficient) + exponent
+		if point <= 0 {
+			return "0." + strings.Repeat("0", -point) + coefficient
+		}
+		return coefficient[:point] + "." + coefficient[point:]
+	}
+	result := coefficient[:1]
+	if len(coefficient) > 1 {
+		result += "." + coefficient[1:]
+	}
+	if adjusted >= 0 {
+		return result + "E+" + strconv.Itoa(adjusted)
+	}
+	return result + "E" + strconv.Itoa(adjusted)
+}
+
+// bigFloatDecimalString returns scientific string of f, rounded to 16 significant digits, without
+// trailing zeros
+func bigFloatDecimalString(f *big.Float) string {
+	if f.IsInf() {
+		if f.Signbit() {
+			return "-Infinity"
+		}
+		return "Infinity"
+	}
+	s := f.Text('e', decimal64Digits-1)
+	mantissa, exponent := s[:strings.IndexByte(s, 'e')], s[strings.IndexByte(s, 'e'):]
+	if strings.Contains(mantissa, ".") {
+		mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+	}
+	return mantissa + exponent
+}
+
+// parseDecimal64 parses decimal string, e.g. "-12.50", "1.5E+10", "Infinity" or "NaN", and returns
+// its decimal64 bits in densely packed decimal encoding
+func parseDecimal64(s string) (uint64, error) {
+	var bits uint64
+	text := s
+	if strings.HasPrefix(text, "-") {
+		bits, text = 1<<63, text[1:]
+	} else if strings.HasPrefix(text, "+") {
+		text = text[1:]
+	}
+	switch strings.ToLower(text) {
+	case "nan":
+		return 0x1f << 58, nil
+	case "inf", "infinity":
+		return bits | 0x1e<<58, nil
+	}
+	exponent := 0
+	if i := strings.IndexAny(text, "eE"); i != -1 {
+		e, err := strconv.Atoi(text[i+1:])
+		if err != nil {
+			return 0, errors.Errorf("bad decimal %q", s)
+		}
+		exponent, text = e, text[:i]
+	}
+	if i := strings.IndexByte(text, '.'); i != -1 {
+		exponent -= len(text) - i - 1
+		text = text[:i] + text[i+1:]
+	}
+	if text == "" || strings.Trim(text, "0123456789") != "" {
+		return 0, errors.Errorf("bad decimal %q", s)
+	}
+	text = strings.TrimLeft(text, "0")
+	// positive exponent, which doesn't fit, is moved to coefficient zeros
+	for exponent > decimal64MaxExp && text != "" && len(text) < decimal64Digits {
+		text += "0"
+		exponent--
+	}
+	if text == "" {
+		text = "0"
+		exponent = clampInt(exponent, -decimal64Bias, decimal64MaxExp)
+	}
+	if len(text) > decimal64Digits {
+		return 0, errors.Errorf("decimal %q has more than %d significant digits", s, decimal64Digits)
+	}
+	if exponent < -decimal64Bias || exponent > decimal64MaxExp {
+		return 0, errors.Errorf("decimal %q exponent is out of range", s)
+	}
+	coefficient, _ := strconv.ParseUint(text, 10, 64)
+	for i := uint(0); i < 5; i++ {
+		bits |= uint64(digits[coefficient%1000]) << (i * 10)
+		coefficient /= 1000
+	}
+	biased := uint64(exponent + decimal64Bias)
+	bits |= (biased & 0xff) << 50
+	if coefficient >= 8 {
+		bits |= (0x18 | biased>>8<<1 | coefficient&1) << 58
+	} else {
+		bits |= (biased>>8<<3 | coefficient) << 58
+	}
+	return bits, nil
+}
+
+// clampInt returns value, limited to [min, max]
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,76 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecimal(t *testing.T) {
+	Convey("Test decimal option", t, func() {
+		type Price struct {
+			Value string `d2b:"decimal:64"`
+		}
+		vectors := []struct {
+			bits  []byte
+			value string
+		}{
+			{[]byte{0x22, 0x38, 0, 0, 0, 0, 0, 0x01}, "1"},
+			{[]byte{0xa2, 0x30, 0, 0, 0, 0, 0x03, 0xd0}, "-7.50"},
+			{[]byte{0x6e, 0x38, 0xff, 0x3f, 0xcf, 0xf3, 0xfc, 0xff}, "9999999999999999"},
+			{[]byte{0x77, 0xfc, 0xff, 0x3f, 0xcf, 0xf3, 0xfc, 0xff}, "9.999999999999999E+384"},
+			{[]byte{0x00, 0, 0, 0, 0, 0, 0, 0x01}, "1E-398"},
+			{[]byte{0x22, 0x2c, 0, 0, 0, 0, 0, 0x00}, "0.000"},
+			{[]byte{0x78, 0, 0, 0, 0, 0, 0, 0}, "Infinity"},
+			{[]byte{0xf8, 0, 0, 0, 0, 0, 0, 0}, "-Infinity"},
+			{[]byte{0x7c, 0, 0, 0, 0, 0, 0, 0}, "NaN"},
+		}
+		Convey("Should decode and encode known decimal64 bit patterns", func() {
+			for _, vector := range vectors {
+				var price Price
+				err := Decode(vector.bits, binary.BigEndian, &price)
+				So(err, ShouldBeNil)
+				So(price.Value, ShouldEqual, vector.value)
+				bytes, err := Encode(price, binary.BigEndian)
+				So(err, ShouldBeNil)
+				So(bytes, ShouldResemble, vector.bits)
+			}
+		})
+		Convey("Should decode every declet value", func() {
+			for value, declet := range digits {
+				So(declets[declet], ShouldEqual, value)
+			}
+		})
+		Convey("Should encode decimal strings in other notations", func() {
+			bytes, err := Encode(Price{Value: "-750E-2"}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0xa2, 0x30, 0, 0, 0, 0, 0x03, 0xd0})
+		})
+		Convey("Should return error for decimals, which don't fit", func() {
+			_, err := Encode(Price{Value: "12345678901234567"}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Price{Value: "1E-399"}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Price{Value: "1.2.3"}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should decode and encode big.Float", func() {
+			type Amount struct {
+				Value *big.Float `d2b:"decimal:64"`
+			}
+			var amount Amount
+			err := Decode([]byte{0xd0, 0x03, 0, 0, 0, 0, 0x30, 0xa2}, binary.LittleEndian, &amount)
+			So(err, ShouldBeNil)
+			f, _ := amount.Value.Float64()
+			So(f, ShouldEqual, -7.5)
+			bytes, err := Encode(amount, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0xa2, 0x34, 0, 0, 0, 0, 0, 0x75})
+			size, err := Size(amount)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, 8)
+		})
+	})
+}
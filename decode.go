@@ -2,140 +2,863 @@ package d2b
 
 import (
 	"encoding/binary"
+	"io"
 	"math"
 	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
-// ConvertBytesToData write byte array to data
-// Can panic, with slice bounds out of range if there's not enough bytes
+// DefaultMaxStringLen is used as string length limit if Decoder.MaxStringLen is not set
+const DefaultMaxStringLen = 1 << 20
+
+// Decoder reads data from bytes buffer. Every Decode call continues from the place,
+// where previous one stopped
+type Decoder struct {
+	// MaxStringLen limits length of strings, which length is read from data (length_from). Decoders, which read
+	// data from io.Reader, also use it as a limit of bytes and elements counts, read from data, because
+	// they can't be checked against remaining bytes. DefaultMaxStringLen is used if it's zero
+	MaxStringLen int
+	// ForceUnsigned makes decoder read signed integers as unsigned ones. Values, which don't fit
+	// destination type, are clamped to its max value
+	ForceUnsigned bool
+	// TagKey is a key of struct tags with decoding options. DefaultTagKey is used if it's empty
+	TagKey string
+	// IgnoreTrailing makes Decode discard bytes, which are left after decoding data, instead of
+	// leaving them for the next Decode call. Discarded bytes are returned by Trailing
+	IgnoreTrailing bool
+	// AllowTruncation makes decoder fill fixed arrays, which extend past the end of buffer, with
+	// remaining bytes and zero the rest, instead of returning error
+	AllowTruncation bool
+	// ErrorHexdump makes decoder add bytes around the failing offset to error messages.
+	// It's off by default, so data doesn't leak to logs
+	ErrorHexdump bool
+	// CaptureReserved makes decoder collect bytes of reserved regions, which are returned by Reserved
+	CaptureReserved bool
+	// ZeroCopyStrings makes decoder create strings, which share memory with decoded bytes, e.g. memory-mapped
+	// file, instead of copying them. Such bytes must not be changed or unmapped while strings are used
+	ZeroCopyStrings bool
+	// OnError is called once per error with dotted path of struct field from the decoded value, e.g.
+	// "Record.Header.Flags", which can't be decoded. If it returns true, the field is left zero and decoding
+	// continues after it. It works only for fields of fixed size, so error of variable-size field is passed
+	// with path of the closest enclosing fixed-size field. Errors, which can't be skipped, abort decoding
+	OnError func(path string, err error) bool
+	// Version is a version of data format. Fields with since/until options, which don't match it, are skipped
+	Version int
+	// HMACKey is a key, used to check fields with hmac option
+	HMACKey []byte
+	// InternStrings makes decoder return the same string for equal decoded strings, so records with repeated
+	// values, e.g. category names, share memory. Interned strings are kept while decoder is used
+	InternStrings bool
+
+	bytes         []byte
+	buffers       [][]byte
+	buffersLength int
+	reader        io.Reader
+	offset        int
+	endian        binary.ByteOrder
+	trailing      []byte
+	reserved      []ReservedRegion
+	scratch       []byte
+	frameStart    int
+	interned      map[string]string
+	allocator     func(t reflect.Type, n int) reflect.Value
+	path          []string
+}
+
+// reportedError is an error, which was passed to OnError, so enclosing fields don't pass it again
+type reportedError struct {
+	error
+}
+
+// Cause returns the reported error
+func (e reportedError) Cause() error {
+	return e.error
+}
+
+// NewDecoder returns decoder, which reads data from bytes
+func NewDecoder(bytes []byte, endian binary.ByteOrder) *Decoder {
+	return &Decoder{bytes: bytes, endian: endian}
+}
+
+// Decode write byte array to data
 func Decode(bytes []byte, endian binary.ByteOrder, data interface{}) error {
+	return NewDecoder(bytes, endian).Decode(data)
+}
+
+// DecodeLimited writes first limit bytes of byte array to data. Fields, which extend past the limit,
+// cause error even if bytes contain more data, e.g. the next frame
+func DecodeLimited(bytes []byte, endian binary.ByteOrder, data interface{}, limit int) error {
+	if limit < 0 || limit > len(bytes) {
+		return errors.Errorf("limit %d is out of bytes with length %d", limit, len(bytes))
+	}
+	return NewDecoder(bytes[:limit:limit], endian).Decode(data)
+}
+
+// Decode reads next bytes to data. Decoder's offset is not moved if error occurs. Reader can't be rewound, so
+// offset of decoder, created with NewReaderDecoder, stays after the last bytes, which were read before error
+func (d *Decoder) Decode(data interface{}) error {
 	t := reflect.TypeOf(data)
-	if t.Kind() != reflect.Ptr {
+	if t == nil || t.Kind() != reflect.Ptr {
 		return errors.New("data should be pointer")
 	}
 	v := reflect.ValueOf(data)
 	if v.IsNil() {
 		return errors.New("can't decode to nil pointer")
 	}
-	_, err := updateValueByTypeFromBytess(v.Elem(), bytes, endian)
-	return err
+	offset, reserved := d.offset, len(d.reserved)
+	d.frameStart = offset
+	d.path = d.path[:0]
+	err := d.decodeValue(v.Elem(), d.endian)
+	if err != nil {
+		if d.ErrorHexdump && d.reader == nil {
+			err = d.hexdumpError(err)
+		}
+		if d.reader == nil {
+			d.offset = offset
+		}
+		d.reserved = d.reserved[:reserved]
+		return err
+	}
+	if d.IgnoreTrailing && d.reader == nil {
+		d.trailing, _ = d.next(d.length() - d.offset)
+	}
+	return nil
+}
+
+// hexdumpError adds up to 16 bytes around the current offset to err
+func (d *Decoder) hexdumpError(err error) error {
+	failed := d.offset
+	start := failed - 8
+	if start < 0 {
+		start = 0
+	}
+	end := failed + 8
+	if end > d.length() {
+		end = d.length()
+	}
+	d.offset = start
+	before, _ := d.next(failed - start)
+	after, _ := d.next(end - failed)
+	return errors.Wrapf(err, "at offset %d, bytes from %d: % x [% x]", failed, start, before, after)
 }
 
-func updateValueByTypeFromBytess(v reflect.Value, bytes []byte, endian binary.ByteOrder) ([]byte, error) {
+// Trailing returns bytes, which were discarded after the last Decode call with IgnoreTrailing option
+func (d *Decoder) Trailing() []byte {
+	return d.trailing
+}
+
+// Offset returns position of the next byte, which will be decoded
+func (d *Decoder) Offset() int {
+	return d.offset
+}
+
+// Remaining returns count of bytes, which are left in buffer after decoded ones.
+// It returns -1 for decoders, which read data from io.Reader
+func (d *Decoder) Remaining() int {
+	if d.reader != nil {
+		return -1
+	}
+	return d.length() - d.offset
+}
+
+// Seek moves decoder to the absolute offset in its buffer, so next Decode call starts from it.
+// It's not supported by decoders, which read data from io.Reader
+func (d *Decoder) Seek(offset int) error {
+	if d.reader != nil {
+		return errors.New("can't seek in reader")
+	}
+	if offset < 0 || offset > d.length() {
+		return errors.Errorf("offset %d is out of buffer with length %d", offset, d.length())
+	}
+	d.offset = offset
+	return nil
+}
+
+// SetScratch makes decoder, which reads data from io.Reader, read fields to scratch instead of allocating
+// buffer for every field. Fields, which are longer than scratch, are read to allocated buffers
+func (d *Decoder) SetScratch(scratch []byte) {
+	d.scratch = scratch
+}
+
+// SetAllocator makes decoder create slices and maps of decoded length with allocate function instead
+// of reflect.MakeSlice and reflect.MakeMap, e.g. to place them in caller's arena. allocate should return
+// slice of type t with length n or empty map of type t. Slices of unknown length, e.g. rest ones, grow on heap
+func (d *Decoder) SetAllocator(allocate func(t reflect.Type, n int) reflect.Value) {
+	d.allocator = allocate
+}
+
+// makeSlice returns slice of type t with length n
+func (d *Decoder) makeSlice(t reflect.Type, n int) reflect.Value {
+	if d.allocator != nil {
+		return d.allocator(t, n)
+	}
+	return reflect.MakeSlice(t, n, n)
+}
+
+// makeMap returns map of type t with space for n entries
+func (d *Decoder) makeMap(t reflect.Type, n int) reflect.Value {
+	if d.allocator != nil {
+		return d.allocator(t, n)
+	}
+	return reflect.MakeMapWithSize(t, n)
+}
+
+// zeroCopyStrings returns true if strings may share memory with decoded bytes. Bytes, read to scratch,
+// are overwritten by the next field
+func (d *Decoder) zeroCopyStrings() bool {
+	return d.ZeroCopyStrings && (d.reader == nil || d.scratch == nil)
+}
+
+func (d *Decoder) maxStringLen() int {
+	if d.MaxStringLen == 0 {
+		return DefaultMaxStringLen
+	}
+	return d.MaxStringLen
+}
+
+// checkAllocation checks, that count of elements, read from data, can be decoded, before memory is allocated
+// for them. Elements of minSize bytes should fit remaining bytes of buffer. Decoders, which read data from
+// io.Reader, limit count with MaxStringLen
+func (d *Decoder) checkAllocation(count uint64, minSize int) error {
+	if count > uint64(maxInt) {
+		return errors.Errorf("count %d is too big", count)
+	}
+	if d.reader != nil {
+		if count > uint64(d.maxStringLen()) {
+			return errors.Errorf("count %d exceeds limit %d", count, d.maxStringLen())
+		}
+		return nil
+	}
+	if minSize > 0 && count > uint64(d.Remaining()/minSize) {
+		return errors.Wrapf(io.ErrUnexpectedEOF, "%d elements of %d bytes don't fit %d remaining bytes", count, minSize, d.Remaining())
+	}
+	return nil
+}
+
+// elementSize returns the smallest count of bytes of slice element, which is a size of zero element.
+// Error is returned, if it's unknown, e.g. for interfaces or truncated arrays
+func (d *Decoder) elementSize(t reflect.Type, tags *structFieldTag) (int, error) {
+	if tags.Stride != 0 {
+		return tags.Stride, nil
+	}
+	if d.AllowTruncation {
+		return 0, errors.New("elements may be truncated")
+	}
+	return (&Encoder{TagKey: d.TagKey, Version: d.Version}).getTypeBytesLength(t)
+}
+
+// decodeAppended decodes length slice elements of unknown size. Slice grows while elements are decoded,
+// so memory isn't allocated for length, read from data, in advance. Elements, which are already in slice,
+// are decoded in place
+func (d *Decoder) decodeAppended(v reflect.Value, length int, tags *structFieldTag, endian binary.ByteOrder) error {
+	result := v
+	for i := 0; i < length; i++ {
+		if i < v.Len() {
+			err := d.decodeElement(v.Index(i), tags, tags.elemEndian(i, endian))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		value := reflect.New(v.Type().Elem()).Elem()
+		err := d.decodeElement(value, tags, tags.elemEndian(i, endian))
+		if err != nil {
+			return err
+		}
+		result = reflect.Append(result, value)
+	}
+	v.Set(result)
+	return nil
+}
+
+// length returns length of data in buffer(s)
+func (d *Decoder) length() int {
+	if d.buffers != nil {
+		return d.buffersLength
+	}
+	return len(d.bytes)
+}
+
+// next returns next n bytes of the buffer (or reader) and moves offset after them
+func (d *Decoder) next(n int) ([]byte, error) {
+	if d.reader != nil {
+		var result []byte
+		if n <= len(d.scratch) {
+			result = d.scratch[:n]
+		} else {
+			result = make([]byte, n)
+		}
+		_, err := io.ReadFull(d.reader, result)
+		if err != nil {
+			return nil, err
+		}
+		d.offset += n
+		return result, nil
+	}
+	if d.buffers != nil {
+		return d.nextFromBuffers(n)
+	}
+	left := len(d.bytes) - d.offset
+	if n > left {
+		return nil, errors.Wrapf(io.ErrUnexpectedEOF, "need %d bytes, but only %d left", n, left)
+	}
+	result := d.bytes[d.offset : d.offset+n]
+	d.offset += n
+	return result, nil
+}
+
+func (d *Decoder) decodeValue(v reflect.Value, endian binary.ByteOrder) error {
 	t := v.Type()
+	endian = typeEndian(t, endian)
 	switch t.Kind() {
 	case reflect.Ptr:
 		if v.IsNil() {
 			v.Set(reflect.New(v.Type().Elem()))
 		}
-		return updateValueByTypeFromBytess(v.Elem(), bytes, endian)
-	case reflect.Int8:
-		v.SetInt(int64(int8(bytes[0])))
-		return bytes[1:], nil
-	case reflect.Int16:
-		val := endian.Uint16(bytes[:2])
-		v.SetInt(int64(int16(val)))
-		return bytes[2:], nil
-	case reflect.Int32:
-		val := endian.Uint32(bytes[:4])
-		v.SetInt(int64(int32(val)))
-		return bytes[4:], nil
-	case reflect.Int64:
-		val := endian.Uint64(bytes[:8])
-		v.SetInt(int64(val))
-		return bytes[8:], nil
-	case reflect.Uint8:
-		v.SetUint(uint64(bytes[0]))
-		return bytes[1:], nil
-	case reflect.Uint16:
-		val := endian.Uint16(bytes[:2])
-		v.SetUint(uint64(int16(val)))
-		return bytes[2:], nil
-	case reflect.Uint32:
-		val := endian.Uint32(bytes[:4])
-		v.SetUint(uint64(int16(val)))
-		return bytes[4:], nil
-	case reflect.Uint64:
-		v.SetUint(endian.Uint64(bytes[:8]))
-		return bytes[8:], nil
-	case reflect.Float32:
-		val := endian.Uint32(bytes[:4])
-		float := math.Float32frombits(val)
-		v.SetFloat(float64(float))
-		return bytes[4:], nil
-	case reflect.Float64:
-		val := endian.Uint64(bytes[:8])
-		float := math.Float64frombits(val)
-		v.SetFloat(float)
-		return bytes[8:], nil
+		return d.decodeValue(v.Elem(), endian)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		bytes, err := d.next(int(t.Size()))
+		if err != nil {
+			return err
+		}
+		d.setNumber(v, bytes, endian)
+		return nil
+	case reflect.Bool:
+		b, err := d.next(1)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b[0] != 0)
+		return nil
 	case reflect.Array:
-		var err error
+		if d.AllowTruncation && d.reader == nil {
+			length, err := (&Encoder{TagKey: d.TagKey, Version: d.Version}).getTypeBytesLength(t)
+			if err == nil && length > d.Remaining() {
+				return d.decodeTruncated(v, length, endian)
+			}
+		}
 		for i := 0; i < v.Len(); i++ {
-			bytes, err = updateValueByTypeFromBytess(v.Index(i), bytes, endian)
+			err := d.decodeValue(v.Index(i), endian)
 			if err != nil {
-				return []byte{}, err
+				return err
 			}
 		}
-		return bytes, nil
+		return nil
 	case reflect.Struct:
-		tags, err := getStructTags(t)
+		info, err := getStructInfo(t, d.TagKey)
 		if err != nil {
-			return []byte{}, errors.Wrap(err, "can't parse struct tags")
+			return errors.Wrap(err, "can't parse struct tags")
+		}
+		tags := info.tags
+		start := d.offset
+		depth := len(d.path)
+		if depth == 0 && d.OnError != nil && t.Name() != "" {
+			d.path = append(d.path, t.Name())
+			depth++
 		}
-		for i := 0; i < t.NumField(); i++ {
-			fv := v.Field(i)
-			bytes, err = updateStructField(fv, bytes, tags[i], endian)
+		// union fields start at the same offset as previous field, the longest of them defines the end
+		unionStart, unionEnd := d.offset, d.offset
+		// offsets of fields, covered by checksums
+		var ranges [][2]int
+		if info.checksums {
+			ranges = make([][2]int, len(tags))
+		}
+		// crc_over fields, which covered fields aren't decoded yet
+		var pendingCRC []int
+		for position, i := range info.order {
+			if tags[i].OmitEmpty && d.AllowTruncation && d.reader == nil && unionEnd == d.length() {
+				zeroTrailer(v, tags, info.order[position:])
+				break
+			}
+			if tags[i].EndianFrom != "" {
+				endian = flagEndian(v.Field(tags[i].endianFromIndex))
+			}
+			if !tags[i].present(d.Version) {
+				if !tags[i].blank {
+					v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+				}
+				continue
+			}
+			if tags[i].Range != "" {
+				if d.reader != nil {
+					return errors.New("range fields can't be decoded from reader")
+				}
+				d.offset = start + tags[i].rangeFrom
+				unionStart = d.offset
+			} else if tags[i].Union {
+				if d.reader != nil {
+					return errors.New("union fields can't be decoded from reader")
+				}
+				d.offset = unionStart
+			} else {
+				d.offset = unionEnd
+				unionStart = unionEnd
+			}
+			fieldStart := d.offset
+			if d.OnError != nil {
+				d.path = append(d.path[:depth], t.Field(i).Name)
+			}
+			err = d.decodeStructField(v, v.Field(i), tags[i], endian)
+			if err == nil && tags[i].Range != "" {
+				err = tags[i].checkRangeSize(d.offset - fieldStart)
+			}
+			if err == nil && tags[i].BOM {
+				endian, err = bomEndian(v.Field(i), endian)
+			}
+			if err == nil && tags[i].CRC32 && tags[i].CRCOver == "" {
+				from, to := tags[i].crcRange(ranges, start, fieldStart)
+				err = d.checkCRC(v.Field(i), from, to)
+			}
+			if err == nil && tags[i].CRCOver != "" {
+				pendingCRC = append(pendingCRC, i)
+			}
+			// checksum, declared before covered field, is checked after it
+			if j := crcOverField(tags, i); err == nil && j != -1 && tags[j].present(d.Version) {
+				err = d.checkCRC(v.Field(j), fieldStart, d.offset)
+				pendingCRC = removeIndex(pendingCRC, j)
+			}
+			if err != nil && d.OnError != nil {
+				err = d.skipFieldError(v.Field(i), tags[i], fieldStart, err)
+			}
+			// authentication failure can't be skipped
+			if err == nil && tags[i].HMAC != "" {
+				err = d.checkHMAC(v.Field(i), tags[i], start, fieldStart)
+			}
 			if err != nil {
 				ft := t.Field(i)
-				return []byte{}, errors.Wrapf(err, "can't update struct field %s.%s", t.Name(), ft.Name)
+				return errors.Wrapf(err, "can't update struct field %s.%s", t.Name(), ft.Name)
+			}
+			if ranges != nil {
+				ranges[i] = [2]int{fieldStart, d.offset}
+			}
+			if d.offset > unionEnd {
+				unionEnd = d.offset
+			}
+		}
+		d.offset = unionEnd
+		if d.OnError != nil {
+			d.path = d.path[:depth]
+		}
+		if len(pendingCRC) != 0 {
+			i := pendingCRC[0]
+			return errors.Errorf("%s.%s checksum can't be checked, because %s field isn't decoded", t.Name(), t.Field(i).Name, tags[i].CRCOver)
+		}
+		if fixedSize := structFixedSize(tags); fixedSize != 0 {
+			if d.offset-start > fixedSize {
+				return errors.Errorf("%s fields take %d bytes, which exceeds fixed size %d", t.Name(), d.offset-start, fixedSize)
+			}
+			err = d.skipReserved(fixedSize - (d.offset - start))
+			if err != nil {
+				return errors.Wrapf(err, "can't skip %s padding", t.Name())
+			}
+		}
+		err = d.setRawSelf(v, tags, start)
+		if err != nil {
+			return errors.Wrapf(err, "can't capture %s bytes", t.Name())
+		}
+		if i := totalLengthField(tags); i != -1 {
+			err = checkTotalLength(v.Field(i), d.offset-start)
+			if err != nil {
+				return errors.Wrapf(err, "bad %s.%s", t.Name(), t.Field(i).Name)
 			}
 		}
-		return bytes, nil
+		if i := echoLengthField(tags); i != -1 {
+			return errors.Wrapf(checkEchoLength(v, i, tags[i], d.offset-start), "bad %s.%s", t.Name(), t.Field(i).Name)
+		}
+		return nil
 	default:
-		return []byte{}, errors.Errorf("type %v is not supported", t.Kind())
+		return unsupportedKindError(t.Kind())
+	}
+}
+
+// skipFieldError checks, whether field, which starts at offset, can be skipped after error using OnError
+// callback. Skipped field is zeroed, decoder moves after it and nil is returned. Otherwise error is returned,
+// marked as reported, if it was passed to OnError
+func (d *Decoder) skipFieldError(v reflect.Value, tags *structFieldTag, offset int, err error) error {
+	if d.reader != nil || isReported(err) {
+		return err
+	}
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
+	p := &planner{encoder: &Encoder{TagKey: d.TagKey, Version: d.Version}}
+	size, sizeErr := p.fieldSize(t, tags)
+	if sizeErr != nil || size == -1 || offset+size > d.length() {
+		return err
+	}
+	if !d.OnError(strings.Join(d.path, "."), err) {
+		return reportedError{err}
+	}
+	v.Set(reflect.Zero(v.Type()))
+	d.offset = offset + size
+	return nil
+}
+
+// isReported returns true if err or any of its causes was passed to OnError
+func isReported(err error) bool {
+	for err != nil {
+		if _, ok := err.(reportedError); ok {
+			return true
+		}
+		cause, ok := err.(interface{ Cause() error })
+		if !ok {
+			return false
+		}
+		err = cause.Cause()
+	}
+	return false
 }
 
-func updateStructField(v reflect.Value, bytes []byte, tags *structFieldTag, endian binary.ByteOrder) ([]byte, error) {
+// setNumber sets numeric value v from bytes, which length equals to v's type size,
+// taking decoder options into account
+func (d *Decoder) setNumber(v reflect.Value, bytes []byte, endian binary.ByteOrder) {
+	if d.ForceUnsigned {
+		switch v.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			setClampedInt(v, readUint(bytes, endian))
+			return
+		}
+	}
+	setNumber(v, bytes, endian)
+}
+
+// setNumber sets numeric value v from bytes, which length equals to v's type size
+func setNumber(v reflect.Value, bytes []byte, endian binary.ByteOrder) {
+	switch v.Kind() {
+	case reflect.Int8:
+		v.SetInt(int64(int8(bytes[0])))
+	case reflect.Int16:
+		v.SetInt(int64(int16(endian.Uint16(bytes))))
+	case reflect.Int32:
+		v.SetInt(int64(int32(endian.Uint32(bytes))))
+	case reflect.Int64:
+		v.SetInt(int64(endian.Uint64(bytes)))
+	case reflect.Uint8:
+		v.SetUint(uint64(bytes[0]))
+	case reflect.Uint16:
+		v.SetUint(uint64(endian.Uint16(bytes)))
+	case reflect.Uint32:
+		v.SetUint(uint64(endian.Uint32(bytes)))
+	case reflect.Uint64:
+		v.SetUint(endian.Uint64(bytes))
+	case reflect.Float32:
+		v.SetFloat(float64(math.Float32frombits(endian.Uint32(bytes))))
+	case reflect.Float64:
+		v.SetFloat(math.Float64frombits(endian.Uint64(bytes)))
+	}
+}
+
+// setClampedInt sets signed integer value v to unsigned value, clamping it to v's max value
+func setClampedInt(v reflect.Value, value uint64) {
+	max := uint64(1)<<(uint(v.Type().Bits())-1) - 1
+	if value > max {
+		value = max
+	}
+	v.SetInt(int64(value))
+}
+
+func (d *Decoder) decodeStructField(parent, v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
 	if tags.Skip {
-		return bytes, nil
+		return nil
+	}
+	if tags.RawSelf {
+		// it's set after the whole struct is decoded
+		return nil
+	}
+	if tags.CurOffset {
+		d.setCurrentOffset(v)
+		return nil
+	}
+	if tags.Endian != nil {
+		endian = tags.Endian
+	}
+	if tags.Fn != "" {
+		return d.decodeFn(parent, tags, endian)
+	}
+	if tags.Transform != "" {
+		return d.decodeTransformed(v, tags)
+	}
+	if tags.BitReverse {
+		return d.decodeBitReversed(parent, v, tags, endian)
+	}
+	if tags.Compress != "" {
+		return d.decodeCompressed(parent, v, tags, endian)
+	}
+	if tags.Decimal != 0 {
+		return d.decodeDecimal(v, endian)
+	}
+	if isBitField(v.Type(), tags) {
+		return d.decodeBitFields(parent, tags)
+	}
+	if tags.blank {
+		length, err := (&Encoder{TagKey: d.TagKey, Version: d.Version}).getStructFieldTypeBytesLength(v.Type(), tags)
+		if err != nil {
+			return err
+		}
+		return d.skipReserved(length)
 	}
 	t := v.Type()
+	endian = typeEndian(t, endian)
 	switch t.Kind() {
 	case reflect.Ptr:
 		if v.IsNil() {
 			v.Set(reflect.New(v.Type().Elem()))
 		}
-		return updateStructField(v.Elem(), bytes, tags, endian)
+		return d.decodeStructField(parent, v.Elem(), tags, endian)
 	case reflect.Slice:
-		if tags.Length == 0 {
-			return nil, errors.New("empty length")
+		if tags.LengthASCII != 0 {
+			return d.decodeASCIILength(v, tags)
 		}
-		var err error
-		for i := 0; i < v.Len(); i++ {
-			bytes, err = updateValueByTypeFromBytess(v.Index(i), bytes, endian)
+		if tags.Bits != 0 {
+			return d.decodeBits(v, tags)
+		}
+		if tags.RLE != 0 && tags.BytePrefix == 0 {
+			return d.decodeRLE(parent, v, tags, endian)
+		}
+		if tags.Rest {
+			return d.decodeRest(v, tags, endian)
+		}
+		if tags.Terminator != "" {
+			return d.decodeTerminated(v, tags, endian)
+		}
+		if tags.CountPrefix != 0 {
+			return d.decodeCountPrefixed(v, tags, endian)
+		}
+		if tags.countExpr != nil {
+			return d.decodeCountExpr(parent, v, tags, endian)
+		}
+		if tags.BytePrefix != 0 {
+			return d.decodeBytePrefixed(parent, v, tags, endian)
+		}
+		if !tags.hasLength() {
+			return errors.New("empty length")
+		}
+		if tags.Pairs != "" {
+			return d.decodePairs(parent, v, tags, endian)
+		}
+		if tags.Interleave != 0 {
+			return d.decodeInterleaved(parent, tags, endian)
+		}
+		length, err := tags.length(parent)
+		if err != nil {
+			return err
+		}
+		err = tags.checkCount(length)
+		if err != nil {
+			return err
+		}
+		if tags.Nibbles {
+			return d.decodeNibbles(v, length)
+		}
+		if tags.WidthFrom != "" {
+			return d.decodeElemWidth(parent, v, length, tags, endian)
+		}
+		// elements, which are already in slice, are decoded in place
+		result := v
+		if v.Len() < length {
+			size, err := d.elementSize(t.Elem(), tags)
+			if err != nil {
+				return d.decodeAppended(v, length, tags, endian)
+			}
+			err = d.checkAllocation(uint64(length), size)
 			if err != nil {
-				return []byte{}, err
+				return err
 			}
+			result = d.makeSlice(t, length)
+			reflect.Copy(result, v)
 		}
-		l := v.Len()
-		for i := 0; i < tags.Length-l; i++ {
-			value := reflect.New(t.Elem())
-			bytes, err = updateValueByTypeFromBytess(value, bytes, endian)
+		if isBulkNumber(t.Elem()) && tags.Stride == 0 && len(tags.AltEndian) == 0 {
+			err = d.decodeNumbers(result, endian)
 			if err != nil {
-				return []byte{}, err
+				return err
 			}
-			v.Set(reflect.Append(v, value.Elem()))
+			v.Set(result)
+			return nil
 		}
-		return bytes, nil
+		for i := 0; i < result.Len(); i++ {
+			err = d.decodeElement(result.Index(i), tags, tags.elemEndian(i, endian))
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(result)
+		return nil
+	case reflect.Map:
+		return d.decodeMap(v, tags, endian)
 	case reflect.String:
-		if tags.Length == 0 {
-			return nil, errors.New("empty length")
+		if tags.LengthASCII != 0 {
+			return d.decodeASCIILength(v, tags)
+		}
+		if tags.Enum != nil {
+			bytes, err := d.next(tags.width(1))
+			if err != nil {
+				return err
+			}
+			name, err := enumName(tags.Enum, int64(readUint(bytes, endian)))
+			if err != nil {
+				return err
+			}
+			v.SetString(name)
+			return nil
+		}
+		if tags.PString != 0 {
+			return d.decodePString(v, tags, endian)
+		}
+		if !tags.hasLength() {
+			return errors.New("empty length")
+		}
+		length, err := tags.length(parent)
+		if err != nil {
+			return err
+		}
+		if (tags.LengthFrom != "" || tags.LengthMethod != "") && length > d.maxStringLen() {
+			return errors.Errorf("string length %d exceeds limit %d", length, d.maxStringLen())
+		}
+		bytes, err := d.next(length)
+		if err != nil {
+			return err
+		}
+		if tags.ASCII7 != "" {
+			bytes = stripHighBits(bytes)
+		}
+		if tags.Pad != "" {
+			for len(bytes) > 0 && bytes[len(bytes)-1] == tags.Pad[0] {
+				bytes = bytes[:len(bytes)-1]
+			}
+			v.SetString(d.toStr(bytes))
+			return nil
+		}
+		v.SetString(d.bytesToStr(bytes))
+		return nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return d.decodeNumberField(v, tags, endian)
+	case reflect.Struct:
+		if tags.NullFlag {
+			return d.decodeNullable(v, endian)
+		}
+		if tags.Flags {
+			return d.decodeFlags(v, tags)
+		}
+	case reflect.Array:
+		if tags.Bitmap {
+			return d.decodeBitmap(v, tags)
 		}
-		v.SetString(bytesToStr(bytes[:tags.Length]))
-		return bytes[tags.Length:], nil
+		if len(tags.AltEndian) != 0 {
+			for i := 0; i < v.Len(); i++ {
+				err := d.decodeValue(v.Index(i), tags.elemEndian(i, endian))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if tags.GUID == "" {
+			break
+		}
+		bytes, err := d.next(16)
+		if err != nil {
+			return err
+		}
+		return setGUID(v, bytes, tags.GUID)
+	case reflect.Interface:
+		if tags.TypeNameFrom != "" {
+			name := parent.Field(tags.typeNameFromIndex).String()
+			t, ok := getNamedType(name)
+			if !ok {
+				return errors.Errorf("type with name %q is not registered", name)
+			}
+			return d.decodeInterfaceValue(v, t, endian)
+		}
+		if tags.TypeIDFrom != "" {
+			id := tags.typeID(parent)
+			t, ok := getRegisteredType(id)
+			if !ok {
+				return errors.Errorf("type with id %d is not registered", id)
+			}
+			return d.decodeInterfaceValue(v, t, endian)
+		}
+		if tags.TypeID == 0 {
+			return errors.New("need to specify typeid width for interface field")
+		}
+		return d.decodeInterface(v, tags.TypeID, endian)
+	}
+	return d.decodeValue(v, endian)
+}
+
+// decodeTruncated decodes value of given length from remaining bytes, padded with zeros
+func (d *Decoder) decodeTruncated(v reflect.Value, length int, endian binary.ByteOrder) error {
+	bytes, err := d.next(d.Remaining())
+	if err != nil {
+		return err
+	}
+	padded := make([]byte, length)
+	copy(padded, bytes)
+	sub := *d
+	sub.bytes, sub.buffers, sub.offset = padded, nil, 0
+	return sub.decodeValue(v, endian)
+}
+
+// decodeInterface reads type id of given width and decodes value of registered type to v
+func (d *Decoder) decodeInterface(v reflect.Value, idWidth int, endian binary.ByteOrder) error {
+	bytes, err := d.next(idWidth)
+	if err != nil {
+		return err
+	}
+	id := readUint(bytes, endian)
+	t, ok := getRegisteredType(id)
+	if !ok {
+		return errors.Errorf("type with id %d is not registered", id)
+	}
+	return d.decodeInterfaceValue(v, t, endian)
+}
+
+// decodeInterfaceValue decodes value of type t and sets it to interface value v
+func (d *Decoder) decodeInterfaceValue(v reflect.Value, t reflect.Type, endian binary.ByteOrder) error {
+	value := reflect.New(t)
+	err := d.decodeValue(value.Elem(), endian)
+	if err != nil {
+		return errors.Wrapf(err, "can't decode %v", t)
+	}
+	switch {
+	case t.AssignableTo(v.Type()):
+		v.Set(value.Elem())
+	case value.Type().AssignableTo(v.Type()):
+		v.Set(value)
+	default:
+		return errors.Errorf("registered type %v doesn't implement %v", t, v.Type())
+	}
+	return nil
+}
+
+// decodeRest decodes slice elements until the end of data. Data shouldn't end in the middle of element
+func (d *Decoder) decodeRest(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	result := reflect.MakeSlice(v.Type(), 0, 0)
+	for d.reader != nil || d.offset < d.length() {
+		start := d.offset
+		value := reflect.New(v.Type().Elem()).Elem()
+		err := d.decodeValue(value, endian)
+		if err != nil {
+			if d.reader != nil && d.offset == start && errors.Cause(err) == io.EOF {
+				break
+			}
+			return errors.Wrapf(err, "can't decode element %d", result.Len())
+		}
+		if d.offset == start {
+			return errors.New("can't decode rest of data to zero length elements")
+		}
+		result = reflect.Append(result, value)
+	}
+	err := tags.checkCount(result.Len())
+	if err != nil {
+		return err
 	}
-	return updateValueByTypeFromBytess(v, bytes, endian)
+	v.Set(result)
+	return nil
 }
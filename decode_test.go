@@ -1,12 +1,39 @@
 package d2b
 
 import (
+	"bytes"
 	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
 	"testing"
+	"unsafe"
 
+	"github.com/pkg/errors"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
+type testShape interface {
+	Area() int32
+}
+
+type testSquare struct {
+	Side int32
+}
+
+func (s testSquare) Area() int32 { return s.Side * s.Side }
+
+type testRect struct {
+	W, H int16
+}
+
+func (r *testRect) Area() int32 { return int32(r.W) * int32(r.H) }
+
+func init() {
+	RegisterType(1, testSquare{})
+	RegisterType(2, &testRect{})
+}
+
 func TestDecode(t *testing.T) {
 	Convey("Test Decode", t, func() {
 		Convey("Should decode int8", func() {
@@ -102,7 +129,7 @@ func TestDecode(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(result, ShouldResemble, Struct{
 				A:     &[]int32{67305985, 67305985},
-				B:     &[]uint32{513, 513},
+				B:     &[]uint32{67305985, 67305985},
 				C:     [2]int32{67305985, 67305985},
 				Test:  "Hell",
 				Test1: "Hell",
@@ -188,6 +215,617 @@ func TestDecode(t *testing.T) {
 			So(err, ShouldNotBeNil)
 			So(result, ShouldResemble, Struct{A: []int{1, 2}})
 		})
+		Convey("Should decode string and slice with length from previous field", func() {
+			type Struct struct {
+				SLen  uint8
+				S     string `d2b:"length_from:SLen"`
+				ALen  *int16
+				A     []uint8 `d2b:"length_from:ALen"`
+				Empty uint8
+				E     string `d2b:"length_from:Empty"`
+			}
+			var result Struct
+			err := Decode([]byte{
+				3, 'a', 'b', 'c',
+				2, 0, 1, 2,
+				0,
+			}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.S, ShouldEqual, "abc")
+			So(*result.ALen, ShouldEqual, 2)
+			So(result.A, ShouldResemble, []uint8{1, 2})
+			So(result.E, ShouldEqual, "")
+		})
+		Convey("Should return error if string length from previous field exceeds limit", func() {
+			type Struct struct {
+				Len uint32
+				S   string `d2b:"length_from:Len"`
+			}
+			var result Struct
+			err := Decode([]byte{255, 255, 255, 255, 'a'}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "exceeds limit")
+
+			decoder := NewDecoder([]byte{3, 0, 0, 0, 'a', 'b', 'c'}, binary.LittleEndian)
+			decoder.MaxStringLen = 2
+			err = decoder.Decode(&result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "exceeds limit")
+		})
+		Convey("Should check slice elements count with min and max", func() {
+			type Struct struct {
+				Count uint8
+				A     []uint8 `d2b:"length_from:Count,min:2,max:3"`
+			}
+			var result Struct
+			err := Decode([]byte{2, 1, 2}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.A, ShouldResemble, []uint8{1, 2})
+
+			result = Struct{}
+			err = Decode([]byte{1, 1}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "less than min")
+
+			result = Struct{}
+			err = Decode([]byte{4, 1, 2, 3, 4}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "greater than max")
+		})
+		Convey("Should return error for bad min and max", func() {
+			type Inverted struct {
+				A []uint8 `d2b:"length:2,max:1,min:4"`
+			}
+			err := Decode([]byte{1, 2}, binary.LittleEndian, &Inverted{})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "field tag error")
+
+			type Negative struct {
+				A []uint8 `d2b:"length:2,min:-1"`
+			}
+			err = Decode([]byte{1, 2}, binary.LittleEndian, &Negative{})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "field tag error")
+		})
+		Convey("Should decode interface fields by registered type id", func() {
+			type Struct struct {
+				A testShape  `d2b:"typeid:u8"`
+				B *testShape `d2b:"typeid:u16"`
+			}
+			var result Struct
+			err := Decode([]byte{
+				1, 3, 0, 0, 0,
+				2, 0, 2, 0, 5, 0,
+			}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.A, ShouldResemble, testSquare{Side: 3})
+			So(*result.B, ShouldResemble, &testRect{W: 2, H: 5})
+			So((*result.B).Area(), ShouldEqual, 10)
+		})
+		Convey("Should return error if interface type id is not registered", func() {
+			type Struct struct {
+				A *testShape `d2b:"typeid:u8"`
+			}
+			var result Struct
+			err := Decode([]byte{100, 1, 2, 3, 4}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should decode guid in ms and rfc4122 layouts", func() {
+			type Struct struct {
+				MS  [16]byte `d2b:"guid:ms"`
+				RFC [16]byte `d2b:"guid:rfc4122"`
+			}
+			// {00112233-4455-6677-8899-aabbccddeeff}
+			guid := [16]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+			var result Struct
+			err := Decode([]byte{
+				0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+				0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+			}, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.MS, ShouldResemble, guid)
+			So(result.RFC, ShouldResemble, guid)
+		})
+		Convey("Should read signed integers as unsigned with ForceUnsigned", func() {
+			type Struct struct {
+				A int16
+				B int8
+				C int32
+				D uint8
+			}
+			var result Struct
+			decoder := NewDecoder([]byte{0x10, 0x00, 0x80, 0xff, 0xff, 0xff, 0xff, 0xff}, binary.LittleEndian)
+			decoder.ForceUnsigned = true
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{A: 16, B: 127, C: 2147483647, D: 255})
+
+			err = Decode([]byte{0x10, 0x00, 0x80, 0xff, 0xff, 0xff, 0xff, 0xff}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{A: 16, B: -128, C: -1, D: 255})
+		})
+		Convey("Should read signed integers of custom width as unsigned with ForceUnsigned", func() {
+			type Struct struct {
+				A int16 `d2b:"width:1"`
+				B int8  `d2b:"width:2"`
+			}
+			var result Struct
+			decoder := NewDecoder([]byte{0xff, 0xff, 0xff}, binary.LittleEndian)
+			decoder.ForceUnsigned = true
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{A: 255, B: 127})
+
+			err = Decode([]byte{0xff, 0xff, 0xff}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{A: -1, B: -1})
+		})
+		Convey("Should return named error for channel field", func() {
+			type Struct struct {
+				Ch chan int
+			}
+			var result Struct
+			err := Decode([]byte{1, 2, 3, 4}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "Struct.Ch")
+			So(err.Error(), ShouldContainSubstring, "chan type can't be encoded or decoded")
+		})
+		Convey("Should apply field endian to all array elements", func() {
+			type Struct struct {
+				A [2]uint8
+				B [3]int16 `d2b:"repeat:3,endian:big"`
+				C int16
+			}
+			var result Struct
+			err := Decode([]byte{1, 2, 0, 1, 0, 2, 1, 0, 1, 0}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{A: [2]uint8{1, 2}, B: [3]int16{1, 2, 256}, C: 1})
+		})
+		Convey("Should return error if repeat doesn't match array length", func() {
+			type Struct struct {
+				B [3]int16 `d2b:"repeat:8"`
+			}
+			type Scalar struct {
+				B int16 `d2b:"repeat:8"`
+			}
+			err := Decode(make([]byte, 16), binary.LittleEndian, &Struct{})
+			So(err, ShouldNotBeNil)
+			err = Decode(make([]byte, 16), binary.LittleEndian, &Scalar{})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should read options from custom tag key", func() {
+			type Struct struct {
+				Len  uint8
+				Name string `wire:"length_from:Len" d2b:"-"`
+				B    uint16 `wire:"endian:big"`
+			}
+			var result Struct
+			decoder := NewDecoder([]byte{2, 'h', 'i', 0, 1}, binary.LittleEndian)
+			decoder.TagKey = "wire"
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{Len: 2, Name: "hi", B: 1})
+		})
+		Convey("Should decode records until the end of data", func() {
+			type Record struct {
+				ID    uint16
+				Value int8
+			}
+			type Struct struct {
+				Header  uint8
+				Records []Record `d2b:"rest:true"`
+			}
+			var result Struct
+			err := Decode([]byte{7, 1, 0, 10, 2, 0, 20}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{Header: 7, Records: []Record{{1, 10}, {2, 20}}})
+
+			result = Struct{}
+			err = Decode([]byte{7}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Records, ShouldBeEmpty)
+
+			result = Struct{}
+			err = Decode([]byte{7, 1, 0, 10, 2, 0}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "element 1")
+		})
+		Convey("Should decode single nested field", func() {
+			type Header struct {
+				Version uint8
+				Flags   uint16 `d2b:"endian:big"`
+			}
+			type Packet struct {
+				ID     uint32
+				Header *Header
+				Body   [2]uint8
+			}
+			result := Packet{ID: 5, Body: [2]uint8{1, 2}}
+			n, err := DecodeField([]byte{0x01, 0x02, 0x03}, binary.LittleEndian, &result, "Header.Flags")
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(result, ShouldResemble, Packet{ID: 5, Header: &Header{Flags: 0x0102}, Body: [2]uint8{1, 2}})
+
+			_, err = DecodeField([]byte{0x01, 0x02}, binary.LittleEndian, &result, "Header.Unknown")
+			So(err, ShouldNotBeNil)
+			_, err = DecodeField([]byte{0x01, 0x02}, binary.LittleEndian, &result, "ID.Value")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should resolve field path before allocating pointers", func() {
+			type Header struct {
+				Flags uint16
+			}
+			type Packet struct {
+				Header *Header
+			}
+			var result Packet
+			_, err := DecodeField([]byte{0x01, 0x02}, binary.LittleEndian, &result, "Header.Unknown")
+			So(err, ShouldNotBeNil)
+			So(result.Header, ShouldBeNil)
+		})
+		Convey("Should decode single field with byte order of parents", func() {
+			RegisterEndian(testBigEndianHeader{}, binary.BigEndian)
+			type Header struct {
+				Order uint8
+				Flags uint16 `d2b:"endian_from:Order"`
+			}
+			type Packet struct {
+				Header   Header `d2b:"endian:big"`
+				Pinned   testBigEndianHeader
+				Override struct{ Flags uint16 } `d2b:"endian:big"`
+			}
+			var result Packet
+			result.Header.Order = 1
+			_, err := DecodeField([]byte{0x01, 0x02}, binary.BigEndian, &result, "Header.Flags")
+			So(err, ShouldBeNil)
+			So(result.Header.Flags, ShouldEqual, 0x0201)
+			_, err = DecodeField([]byte{0x01, 0x02}, binary.LittleEndian, &result, "Override.Flags")
+			So(err, ShouldBeNil)
+			So(result.Override.Flags, ShouldEqual, 0x0102)
+			_, err = DecodeField([]byte{0x01, 0x02}, binary.LittleEndian, &result, "Pinned.Flags")
+			So(err, ShouldBeNil)
+			So(result.Pinned.Flags, ShouldEqual, 0x0102)
+		})
+		Convey("Should zero single field, which isn't present in decoder version", func() {
+			type Packet struct {
+				ID    uint8
+				Flags uint16 `d2b:"since:2"`
+			}
+			result := Packet{Flags: 7}
+			decoder := NewDecoder([]byte{0x01, 0x02}, binary.LittleEndian)
+			decoder.Version = 1
+			So(decoder.DecodeField(&result, "Flags"), ShouldBeNil)
+			So(result.Flags, ShouldEqual, 0)
+			So(decoder.Remaining(), ShouldEqual, 2)
+		})
+		Convey("Should discard trailing bytes with IgnoreTrailing", func() {
+			type Known struct {
+				Version uint8
+				Length  uint16
+			}
+			var result Known
+			decoder := NewDecoder([]byte{1, 2, 0, 0xaa, 0xbb}, binary.LittleEndian)
+			decoder.IgnoreTrailing = true
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Known{Version: 1, Length: 2})
+			So(decoder.Trailing(), ShouldResemble, []byte{0xaa, 0xbb})
+
+			err = decoder.Decode(&result)
+			So(err, ShouldNotBeNil)
+
+			decoder = NewDecoder([]byte{1, 2, 0, 3, 4, 0}, binary.LittleEndian)
+			So(decoder.Decode(&result), ShouldBeNil)
+			So(decoder.Trailing(), ShouldBeEmpty)
+			So(decoder.Decode(&result), ShouldBeNil)
+			So(result, ShouldResemble, Known{Version: 3, Length: 4})
+		})
+		Convey("Should decode anonymous struct with tags", func() {
+			result := &struct {
+				A    uint16
+				Len  uint8
+				Name string `d2b:"length_from:Len"`
+				B    struct {
+					C uint32 `d2b:"endian:big"`
+				}
+			}{}
+			var data interface{} = result
+			err := Decode([]byte{1, 0, 2, 'h', 'i', 0, 0, 0, 5}, binary.LittleEndian, data)
+			So(err, ShouldBeNil)
+			So(result.A, ShouldEqual, 1)
+			So(result.Name, ShouldEqual, "hi")
+			So(result.B.C, ShouldEqual, 5)
+			bytes, err := Encode(*result, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 0, 2, 'h', 'i', 0, 0, 0, 5})
+		})
+		Convey("Should skip padding between slice elements with stride", func() {
+			type Element struct {
+				A uint16
+				B uint32
+			}
+			type Table struct {
+				Count    uint8
+				Elements []Element `d2b:"length_from:Count,stride:8"`
+			}
+			data := Table{Count: 2, Elements: []Element{{A: 1, B: 2}, {A: 3, B: 4}}}
+			wire := []byte{2, 1, 0, 2, 0, 0, 0, 0, 0, 3, 0, 4, 0, 0, 0, 0, 0}
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+
+			wire[7], wire[8] = 0xff, 0xff
+			var result Table
+			err = Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+
+			type Bad struct {
+				Elements []Element `d2b:"length:1,stride:4"`
+			}
+			So(Decode(wire, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+		})
+		Convey("Should add hexdump to errors with ErrorHexdump", func() {
+			type Frame struct {
+				Header [10]byte
+				Kind   uint8 `d2b:"enum:A=1|B=2"`
+				Tail   uint32
+			}
+			wire := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0x0a, 0x0b, 0x0c}
+			var result Frame
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldNotContainSubstring, "03 04")
+
+			decoder := NewDecoder(wire, binary.LittleEndian)
+			decoder.ErrorHexdump = true
+			err = decoder.Decode(&result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "at offset 11, bytes from 3: 03 04 05 06 07 08 09 0a [0b 0c]")
+			So(decoder.Offset(), ShouldEqual, 0)
+		})
+		Convey("Should copy strings by default and share bytes with ZeroCopyStrings", func() {
+			type Text struct {
+				Title string `d2b:"length:4"`
+				Body  string `d2b:"length:3,pad:0x20"`
+			}
+			wire := []byte{'a', 'b', 0, 0, 'c', ' ', ' '}
+			var result Text
+			So(Decode(wire, binary.LittleEndian, &result), ShouldBeNil)
+			So(result, ShouldResemble, Text{Title: "ab", Body: "c"})
+
+			decoder := NewDecoder(wire, binary.LittleEndian)
+			decoder.ZeroCopyStrings = true
+			var shared Text
+			So(decoder.Decode(&shared), ShouldBeNil)
+			So(shared, ShouldResemble, Text{Title: "ab", Body: "c"})
+
+			wire[0], wire[4] = 'x', 'y'
+			So(result, ShouldResemble, Text{Title: "ab", Body: "c"})
+			So(shared, ShouldResemble, Text{Title: "xb", Body: "y"})
+		})
+		Convey("Should allocate slices and maps with allocator", func() {
+			type Record struct {
+				Len    uint8
+				Values []uint16        `d2b:"length_from:Len"`
+				Tags   map[uint8]uint8 `d2b:"count_prefix:u8"`
+				Counts []uint32        `d2b:"count_prefix:u8"`
+			}
+			arena := make([]uint16, 0, 16)
+			var allocated []string
+			decoder := NewDecoder([]byte{3, 1, 0, 2, 0, 3, 0, 1, 5, 6, 1, 9, 0, 0, 0}, binary.LittleEndian)
+			decoder.SetAllocator(func(t reflect.Type, n int) reflect.Value {
+				allocated = append(allocated, t.String())
+				if t == reflect.TypeOf(arena) {
+					arena = arena[:len(arena)+n]
+					return reflect.ValueOf(arena[len(arena)-n:])
+				}
+				if t.Kind() == reflect.Map {
+					return reflect.MakeMap(t)
+				}
+				return reflect.MakeSlice(t, n, n)
+			})
+			var record Record
+			So(decoder.Decode(&record), ShouldBeNil)
+			So(record, ShouldResemble, Record{Len: 3, Values: []uint16{1, 2, 3}, Tags: map[uint8]uint8{5: 6}, Counts: []uint32{9}})
+			So(allocated, ShouldResemble, []string{"[]uint16", "map[uint8]uint8", "[]uint32"})
+			So(arena[:3], ShouldResemble, []uint16{1, 2, 3})
+		})
+		Convey("Should check length from data before allocating slice", func() {
+			type Values struct {
+				Len    uint32
+				Values []uint64 `d2b:"length_from:Len"`
+			}
+			var values Values
+			err := Decode([]byte{0xff, 0xff, 0xff, 0x7f, 1, 2}, binary.LittleEndian, &values)
+			So(errors.Cause(err), ShouldEqual, io.ErrUnexpectedEOF)
+
+			decoder := NewReaderDecoder(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0x7f, 1, 2}), binary.LittleEndian)
+			So(decoder.Decode(&values), ShouldNotBeNil)
+
+			grid := testGrid{Rows: 0xff, Cols: 0xff}
+			b, err := Encode(grid, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(Decode(b[:4], binary.LittleEndian, &grid), ShouldNotBeNil)
+		})
+		Convey("Should append slice elements of unknown size while decoding", func() {
+			type Section struct {
+				Data []byte `d2b:"byte_prefix:u8"`
+			}
+			type Sections struct {
+				Len      uint32
+				Sections []Section `d2b:"length_from:Len"`
+			}
+			decoder := NewDecoder([]byte{2, 0, 0, 0, 1, 7, 0}, binary.LittleEndian)
+			decoder.AllowTruncation = true
+			var sections Sections
+			So(decoder.Decode(&sections), ShouldBeNil)
+			So(sections.Sections, ShouldResemble, []Section{{Data: []byte{7}}, {Data: []byte{}}})
+			err := Decode([]byte{0xff, 0xff, 0xff, 0x7f, 1, 2}, binary.LittleEndian, &sections)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should decode numeric slices with single read in both byte orders", func() {
+			type Samples struct {
+				Floats []float32 `d2b:"length:2"`
+				Ints   []int16   `d2b:"length:2"`
+			}
+			for _, endian := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+				samples := Samples{Floats: []float32{1.5, -2}, Ints: []int16{-3, 4}}
+				wire, err := Encode(samples, endian)
+				So(err, ShouldBeNil)
+				var decoded Samples
+				err = Decode(wire, endian, &decoded)
+				So(err, ShouldBeNil)
+				So(decoded, ShouldResemble, samples)
+				err = Decode(wire[:11], endian, &decoded)
+				So(err, ShouldNotBeNil)
+			}
+			decoder := NewDecoder([]byte{0xff, 0xff, 0x01, 0x00}, binary.LittleEndian)
+			decoder.ForceUnsigned = true
+			var ints struct {
+				Values []int16 `d2b:"length:2"`
+			}
+			So(decoder.Decode(&ints), ShouldBeNil)
+			So(ints.Values, ShouldResemble, []int16{32767, 1})
+		})
+		Convey("Should share equal strings with InternStrings", func() {
+			type Item struct {
+				Category string `d2b:"length:4"`
+			}
+			decoder := NewDecoder([]byte("toolfoodtool"), binary.LittleEndian)
+			decoder.InternStrings = true
+			var first, second, third Item
+			So(decoder.Decode(&first), ShouldBeNil)
+			So(decoder.Decode(&second), ShouldBeNil)
+			So(decoder.Decode(&third), ShouldBeNil)
+			So(first.Category, ShouldEqual, "tool")
+			So(third.Category, ShouldEqual, first.Category)
+			So(stringData(third.Category), ShouldEqual, stringData(first.Category))
+			So(stringData(second.Category), ShouldNotEqual, stringData(first.Category))
+		})
+		Convey("Should strip parity bits of 7-bit ASCII strings", func() {
+			type Serial struct {
+				Command string `d2b:"length:4,ascii7:even"`
+				Reply   string `d2b:"length:2,ascii7:true"`
+			}
+			wire := []byte{'A', 'T' | 0x80, 'Z', 0, 'O' | 0x80, 'K' | 0x80}
+			var result Serial
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Serial{Command: "ATZ", Reply: "OK"})
+			So(wire[1], ShouldEqual, 'T'|0x80)
+
+			bytes, err := Encode(result, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{'A', 'T' | 0x80, 'Z', 0, 'O', 'K'})
+
+			_, err = Encode(Serial{Command: "é"}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should trim pad bytes of strings with length from previous field", func() {
+			type Region struct {
+				RegionLen uint8
+				Name      string `d2b:"length_from:RegionLen,pad:0"`
+				Label     string `d2b:"length:6,pad:0x20"`
+			}
+			wire := []byte{5, 'a', 0, 'b', 0, 0, 'x', ' ', 'y', ' ', ' ', ' '}
+			var result Region
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Region{RegionLen: 5, Name: "a\x00b", Label: "x y"})
+			bytes, err := Encode(result, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should not read past the limit in DecodeLimited", func() {
+			type Frame struct {
+				Len     uint8
+				Payload []byte `d2b:"length_from:Len"`
+			}
+			frames := []byte{2, 'h', 'i', 3, 'a', 'b', 'c'}
+			var result Frame
+			So(DecodeLimited(frames, binary.LittleEndian, &result, 3), ShouldBeNil)
+			So(result, ShouldResemble, Frame{Len: 2, Payload: []byte("hi")})
+
+			frames[0] = 4
+			So(Decode(frames, binary.LittleEndian, &result), ShouldBeNil)
+			So(DecodeLimited(frames, binary.LittleEndian, &result, 3), ShouldNotBeNil)
+			So(DecodeLimited(frames, binary.LittleEndian, &result, 8), ShouldNotBeNil)
+		})
+		Convey("Should pad truncated arrays with AllowTruncation", func() {
+			type Record struct {
+				ID   uint8
+				Data [32]byte
+			}
+			wire := make([]byte, 20)
+			for i := range wire {
+				wire[i] = byte(i + 1)
+			}
+			var result Record
+			So(Decode(wire, binary.LittleEndian, &result), ShouldNotBeNil)
+
+			decoder := NewDecoder(wire, binary.LittleEndian)
+			decoder.AllowTruncation = true
+			So(decoder.Decode(&result), ShouldBeNil)
+			So(result.ID, ShouldEqual, 1)
+			So(result.Data[:19], ShouldResemble, wire[1:])
+			So(result.Data[19:], ShouldResemble, make([]byte, 13))
+			So(decoder.Remaining(), ShouldEqual, 0)
+		})
+		Convey("Should return count of remaining bytes", func() {
+			decoder := NewDecoder([]byte{1, 2, 3, 4, 5}, binary.LittleEndian)
+			So(decoder.Remaining(), ShouldEqual, 5)
+			var a uint16
+			So(decoder.Decode(&a), ShouldBeNil)
+			So(decoder.Remaining(), ShouldEqual, 3)
+			So(decoder.Decode(&a), ShouldBeNil)
+			So(decoder.Remaining(), ShouldEqual, 1)
+			So(decoder.Decode(&a), ShouldNotBeNil)
+			So(decoder.Remaining(), ShouldEqual, 1)
+			So(NewBuffersDecoder([][]byte{{1}, {2, 3}}, binary.LittleEndian).Remaining(), ShouldEqual, 3)
+		})
+		Convey("Should decode record at offset, read from directory entry", func() {
+			type Entry struct {
+				ID     uint8
+				Offset uint8
+			}
+			type Record struct {
+				A uint16
+				B uint8
+			}
+			decoder := NewDecoder([]byte{7, 4, 0xff, 0xff, 1, 2, 3}, binary.LittleEndian)
+			var entry Entry
+			So(decoder.Decode(&entry), ShouldBeNil)
+			So(decoder.Offset(), ShouldEqual, 2)
+			So(decoder.Seek(int(entry.Offset)), ShouldBeNil)
+			var record Record
+			So(decoder.Decode(&record), ShouldBeNil)
+			So(record, ShouldResemble, Record{A: 0x0201, B: 3})
+			So(decoder.Seek(0), ShouldBeNil)
+			So(decoder.Decode(&entry), ShouldBeNil)
+			So(entry, ShouldResemble, Entry{ID: 7, Offset: 4})
+			So(decoder.Seek(8), ShouldNotBeNil)
+			So(decoder.Seek(-1), ShouldNotBeNil)
+		})
+		Convey("Should return error if length_from refers to bad field", func() {
+			type After struct {
+				S   string `d2b:"length_from:Len"`
+				Len uint8
+			}
+			type NotInt struct {
+				Len float32
+				S   string `d2b:"length_from:Len"`
+			}
+			err := Decode([]byte{1, 2, 3, 4, 5}, binary.LittleEndian, &After{})
+			So(err, ShouldNotBeNil)
+			err = Decode([]byte{1, 2, 3, 4, 5}, binary.LittleEndian, &NotInt{})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if there's not enough bytes", func() {
+			var result int32
+			err := Decode([]byte{1, 2}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
 		Convey("Should return error if trying to decode struct array field with bad elements", func() {
 			type Struct struct {
 				A [2]int
@@ -199,5 +837,166 @@ func TestDecode(t *testing.T) {
 			So(err, ShouldNotBeNil)
 			So(result, ShouldResemble, Struct{})
 		})
+		Convey("Should skip corrupt fields with OnError", func() {
+			type Record struct {
+				ID    uint16
+				Level uint8 `d2b:"enum:1|2|3"`
+				Flags [2]byte
+				Len   uint8
+				Text  string `d2b:"length_from:Len"`
+			}
+			wire := []byte{1, 0, 9, 0xaa, 0xbb, 2, 'o', 'k'}
+			var paths []string
+			decoder := NewDecoder(wire, binary.LittleEndian)
+			decoder.OnError = func(path string, err error) bool {
+				paths = append(paths, path)
+				return true
+			}
+			result := Record{Level: 1}
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{"Record.Level"})
+			So(result, ShouldResemble, Record{ID: 1, Flags: [2]byte{0xaa, 0xbb}, Len: 2, Text: "ok"})
+
+			Convey("Should abort if OnError returns false", func() {
+				decoder := NewDecoder(wire, binary.LittleEndian)
+				decoder.OnError = func(path string, err error) bool { return false }
+				So(decoder.Decode(&Record{}), ShouldNotBeNil)
+			})
+			Convey("Should pass error once with path from decoded value", func() {
+				type Header struct {
+					Level uint8 `d2b:"enum:1|2|3"`
+					Flags [2]byte
+				}
+				type Packet struct {
+					ID     uint16
+					Header Header
+					Body   struct{ Header Header }
+				}
+				var paths []string
+				decoder := NewDecoder([]byte{1, 0, 1, 0, 0, 9, 0, 0}, binary.LittleEndian)
+				decoder.OnError = func(path string, err error) bool {
+					paths = append(paths, path)
+					return false
+				}
+				So(decoder.Decode(&Packet{}), ShouldNotBeNil)
+				So(paths, ShouldResemble, []string{"Packet.Body.Header.Level"})
+
+				paths = nil
+				decoder = NewDecoder([]byte{1, 0, 9, 0, 0, 9, 0, 0}, binary.LittleEndian)
+				decoder.OnError = func(path string, err error) bool {
+					paths = append(paths, path)
+					return true
+				}
+				So(decoder.Decode(&Packet{}), ShouldBeNil)
+				So(paths, ShouldResemble, []string{"Packet.Header.Level", "Packet.Body.Header.Level"})
+			})
+			Convey("Should abort on variable-length field errors", func() {
+				decoder := NewDecoder([]byte{1, 0, 1, 0xaa, 0xbb, 9, 'o', 'k'}, binary.LittleEndian)
+				decoder.OnError = func(path string, err error) bool { return true }
+				So(decoder.Decode(&Record{}), ShouldNotBeNil)
+			})
+		})
 	})
 }
+
+type testBigEndianHeader struct {
+	Flags uint16
+}
+
+func benchmarkDecodeStrings(b *testing.B, zeroCopy bool) {
+	type Text struct {
+		Len  uint16
+		Body string `d2b:"length_from:Len"`
+	}
+	wire := make([]byte, 2+4096)
+	binary.LittleEndian.PutUint16(wire, 4096)
+	for i := range wire[2:] {
+		wire[2+i] = 'a'
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decoder := NewDecoder(wire, binary.LittleEndian)
+		decoder.ZeroCopyStrings = zeroCopy
+		var result Text
+		err := decoder.Decode(&result)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeStrings(b *testing.B) {
+	benchmarkDecodeStrings(b, false)
+}
+
+func BenchmarkDecodeStringsZeroCopy(b *testing.B) {
+	benchmarkDecodeStrings(b, true)
+}
+
+// stringData returns address of string bytes
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func benchmarkDecodeCategories(b *testing.B, intern bool) {
+	type Item struct {
+		ID       uint32
+		Category string `d2b:"length:8"`
+	}
+	var wire []byte
+	for i := 0; i < 1000; i++ {
+		item, err := Encode(Item{ID: uint32(i), Category: []string{"books", "music", "games"}[i%3]}, binary.LittleEndian)
+		if err != nil {
+			b.Fatal(err)
+		}
+		wire = append(wire, item...)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decoder := NewDecoder(wire, binary.LittleEndian)
+		decoder.InternStrings = intern
+		items := make([]Item, 1000)
+		for j := range items {
+			err := decoder.Decode(&items[j])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeCategories(b *testing.B) {
+	benchmarkDecodeCategories(b, false)
+}
+
+func BenchmarkDecodeCategoriesInterned(b *testing.B) {
+	benchmarkDecodeCategories(b, true)
+}
+
+func benchmarkDecodeFloats(b *testing.B, endian binary.ByteOrder) {
+	type Samples struct {
+		Values []float32 `d2b:"length:1000000"`
+	}
+	wire := make([]byte, 4*1000000)
+	for i := 0; i < 1000000; i++ {
+		endian.PutUint32(wire[4*i:], math.Float32bits(float32(i)))
+	}
+	b.SetBytes(int64(len(wire)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var result Samples
+		err := Decode(wire, endian, &result)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeFloatsLittleEndian(b *testing.B) {
+	benchmarkDecodeFloats(b, binary.LittleEndian)
+}
+
+func BenchmarkDecodeFloatsBigEndian(b *testing.B) {
+	benchmarkDecodeFloats(b, binary.BigEndian)
+}
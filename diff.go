@@ -0,0 +1,112 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FieldDiff describes struct field, which encoded bytes differ
+type FieldDiff struct {
+	// Path is dot separated path to the field, e.g. "Header.Flags"
+	Path string
+	// Offset is a field offset from the beginning of data or -1 if it depends on previous fields values
+	Offset int
+	// A and B are field bytes of encoded values. They are nil if field offset or size is unknown,
+	// in this case field values are compared instead
+	A, B []byte
+}
+
+// DiffBytes encodes two values of the same struct type and returns fields, which bytes differ
+func DiffBytes(a, b interface{}, endian binary.ByteOrder) ([]FieldDiff, error) {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return nil, errors.Errorf("can't diff values of different types %T and %T", a, b)
+	}
+	plans, err := Plan(a)
+	if err != nil {
+		return nil, err
+	}
+	aBytes, err := Encode(a, endian)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't encode first value")
+	}
+	bBytes, err := Encode(b, endian)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't encode second value")
+	}
+	var result []FieldDiff
+	for _, plan := range plans {
+		end := addOffset(plan.Offset, plan.Size)
+		if end != -1 && end <= len(aBytes) && end <= len(bBytes) {
+			aField, bField := aBytes[plan.Offset:end], bBytes[plan.Offset:end]
+			if !bytes.Equal(aField, bField) {
+				result = append(result, FieldDiff{Path: plan.Path, Offset: plan.Offset, A: aField, B: bField})
+			}
+			continue
+		}
+		if !reflect.DeepEqual(fieldByPath(reflect.ValueOf(a), plan.Path), fieldByPath(reflect.ValueOf(b), plan.Path)) {
+			result = append(result, FieldDiff{Path: plan.Path, Offset: -1})
+		}
+	}
+	return result, nil
+}
+
+// fieldByPath returns value of struct field with dot separated path. Nil pointers on the path
+// are treated as pointers to zero values
+func fieldByPath(v reflect.Value, path string) interface{} {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v = reflect.Zero(v.Type().Elem())
+				continue
+			}
+			v = v.Elem()
+		}
+		v = v.FieldByName(name)
+	}
+	return v.Interface()
+}
+
+// EncodeDelta encodes fields of next struct value, which differ from fields of prev one. Every changed field
+// is prefixed with its one-byte index in the struct. Unchanged fields are omitted
+func EncodeDelta(prev, next interface{}, endian binary.ByteOrder) ([]byte, error) {
+	if reflect.TypeOf(prev) != reflect.TypeOf(next) {
+		return nil, errors.Errorf("can't diff values of different types %T and %T", prev, next)
+	}
+	prevValue, nextValue := reflect.ValueOf(prev), reflect.ValueOf(next)
+	for nextValue.Kind() == reflect.Ptr {
+		if prevValue.IsNil() || nextValue.IsNil() {
+			return nil, errors.New("can't diff nil values")
+		}
+		prevValue, nextValue = prevValue.Elem(), nextValue.Elem()
+	}
+	t := nextValue.Type()
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("data should be struct, not %v", t)
+	}
+	if t.NumField() > 256 {
+		return nil, errors.Errorf("%v has too many fields for one-byte indexes", t)
+	}
+	e := NewEncoder(endian)
+	info, err := getStructInfo(t, e.TagKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %v struct tags error", t.Name())
+	}
+	buffer := bytes.NewBuffer(nil)
+	for _, i := range info.order {
+		tag := info.tags[i]
+		if tag.Skip || tag.blank || tag.CurOffset || tag.RawSelf || !tag.present(e.Version) ||
+			reflect.DeepEqual(prevValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+		buffer.WriteByte(byte(i))
+		err = e.structFieldValueToBytes(nextValue, nextValue.Field(i), tag, buffer, endian)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't encode %v.%v field to bytes", t.Name(), t.Field(i).Name)
+		}
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffBytes(t *testing.T) {
+	Convey("Test DiffBytes", t, func() {
+		type Header struct {
+			Version uint8
+			Flags   uint16
+		}
+		type Frame struct {
+			Header  Header
+			Seq     uint32
+			Len     uint8
+			Payload []byte `d2b:"length_from:Len"`
+		}
+		a := Frame{Header: Header{Version: 1, Flags: 2}, Seq: 10, Len: 2, Payload: []byte{1, 2}}
+		Convey("Should return field, which differs", func() {
+			b := a
+			b.Header.Flags = 0x0300
+			diff, err := DiffBytes(a, b, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(diff, ShouldResemble, []FieldDiff{{Path: "Header.Flags", Offset: 1, A: []byte{2, 0}, B: []byte{0, 3}}})
+		})
+		Convey("Should compare values of fields with unknown offset", func() {
+			b := a
+			b.Payload = []byte{1, 3}
+			diff, err := DiffBytes(&a, &b, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(diff, ShouldResemble, []FieldDiff{{Path: "Payload", Offset: -1}})
+		})
+		Convey("Should return nothing for equal values", func() {
+			diff, err := DiffBytes(a, a, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(diff, ShouldBeEmpty)
+		})
+		Convey("Should return error for values of different types", func() {
+			_, err := DiffBytes(a, &a, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestEncodeDelta(t *testing.T) {
+	Convey("Test EncodeDelta", t, func() {
+		type State struct {
+			Mode  uint8
+			Level uint16
+			Name  string `d2b:"length:4"`
+		}
+		a := State{Mode: 1, Level: 10, Name: "idle"}
+		Convey("Should encode only changed field, prefixed with its index", func() {
+			b := a
+			b.Level = 0x0102
+			delta, err := EncodeDelta(a, b, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(delta, ShouldResemble, []byte{1, 2, 1})
+		})
+		Convey("Should return empty delta for equal values", func() {
+			delta, err := EncodeDelta(&a, &a, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(delta, ShouldBeEmpty)
+		})
+		Convey("Should return error for values of different types", func() {
+			_, err := EncodeDelta(a, &a, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,59 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parseDurationUnit parses duration unit names: ns, us, ms, s
+func parseDurationUnit(name string) (time.Duration, error) {
+	switch name {
+	case "ns":
+		return time.Nanosecond, nil
+	case "us":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	}
+	return 0, errors.Errorf("bad duration unit %q", name)
+}
+
+// checkDuration checks, that field with duration option is time.Duration with valid width
+func checkDuration(t reflect.Type, width int) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != durationType {
+		return errors.Errorf("duration field should be time.Duration, not %v", t)
+	}
+	switch width {
+	case 0, 1, 2, 4, 8:
+		return nil
+	}
+	return errors.Errorf("bad duration width %d", width)
+}
+
+// durationToBytes writes duration as integer count of units. Duration, which isn't a whole count of units or
+// doesn't fit the width, is an error, unless saturate option is set: then it's truncated and clamped
+func (e *Encoder) durationToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	count := v.Int() / int64(ft.Duration)
+	width := ft.width(8)
+	if !ft.Saturate {
+		if v.Int()%int64(ft.Duration) != 0 {
+			return errors.Errorf("duration %v isn't a whole count of %v", time.Duration(v.Int()), ft.Duration)
+		}
+		max := int64(1)<<uint(8*width-1) - 1
+		if width < 8 && (count > max || count < -max-1) {
+			return errors.Errorf("duration %v doesn't fit %d-byte integer", time.Duration(v.Int()), width)
+		}
+	}
+	return e.putInt(count, width, ft, buffer, endian)
+}
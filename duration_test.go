@@ -0,0 +1,77 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDuration(t *testing.T) {
+	Convey("Test duration fields", t, func() {
+		type Struct struct {
+			NS time.Duration  `d2b:"duration:ns"`
+			US time.Duration  `d2b:"duration:us,width:4"`
+			MS *time.Duration `d2b:"duration:ms,width:2"`
+			S  time.Duration  `d2b:"duration:s,width:1"`
+		}
+		ms := -1500 * time.Millisecond
+		data := Struct{
+			NS: 1500 * time.Nanosecond,
+			US: 2 * time.Second,
+			MS: &ms,
+			S:  time.Minute,
+		}
+		expected := []byte{
+			0xdc, 0x05, 0, 0, 0, 0, 0, 0,
+			0x80, 0x84, 0x1e, 0x00,
+			0x24, 0xfa,
+			60,
+		}
+		Convey("Should encode durations in declared units", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, expected)
+		})
+		Convey("Should decode durations in declared units", func() {
+			var result Struct
+			err := Decode(expected, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should encode nil struct with duration fields", func() {
+			var data *Struct
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldHaveLength, 15)
+		})
+		Convey("Should return error if duration isn't a whole count of units or doesn't fit width", func() {
+			_, err := Encode(Struct{NS: 1, US: 1500 * time.Nanosecond, MS: &ms}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Struct{NS: 1, MS: &ms, S: 128 * time.Second}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should truncate and clamp duration with saturate option", func() {
+			type Saturated struct {
+				S time.Duration `d2b:"duration:s,width:1,saturate:true"`
+			}
+			bytes, err := Encode(Saturated{S: 1500 * time.Millisecond}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1})
+			bytes, err = Encode(Saturated{S: time.Hour}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{127})
+		})
+		Convey("Should return error for duration option on non-duration field", func() {
+			type Bad struct {
+				A int64 `d2b:"duration:ms"`
+			}
+			type BadWidth struct {
+				A time.Duration `d2b:"duration:ms,width:3"`
+			}
+			So(Decode(make([]byte, 8), binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+			So(Decode(make([]byte, 8), binary.LittleEndian, &BadWidth{}), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,76 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// checkElemWidth checks, that field with elem_width_from option is slice of 16, 32 or 64-bit integers
+func checkElemWidth(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice {
+		return errors.Errorf("field with elem_width_from option should be slice, not %v", t)
+	}
+	if !isInteger(t.Elem().Kind()) || t.Elem().Size() < 2 {
+		return errors.Errorf("field with elem_width_from option should contain 16, 32 or 64-bit integers, not %v", t.Elem())
+	}
+	return nil
+}
+
+// elemWidth returns width of slice elements, selected by width flag field: full size of element type
+// if its bit 0 is set, half of it otherwise
+func (t *structFieldTag) elemWidth(parent reflect.Value, elem reflect.Type) int {
+	if uintValue(parent.Field(t.widthFromIndex))&1 != 0 {
+		return int(elem.Size())
+	}
+	return int(elem.Size()) / 2
+}
+
+// decodeElemWidth decodes length integers of width, selected by elem_width_from field. Narrow signed
+// integers are sign-extended
+func (d *Decoder) decodeElemWidth(parent, v reflect.Value, length int, tags *structFieldTag, endian binary.ByteOrder) error {
+	width := tags.elemWidth(parent, v.Type().Elem())
+	err := d.checkAllocation(uint64(length), width)
+	if err != nil {
+		return err
+	}
+	result := d.makeSlice(v.Type(), length)
+	for i := 0; i < length; i++ {
+		bytes, err := d.next(width)
+		if err != nil {
+			return errors.Wrapf(err, "can't decode element %d", i)
+		}
+		if isSigned(result.Index(i).Kind()) {
+			result.Index(i).SetInt(readInt(bytes, endian))
+		} else {
+			result.Index(i).SetUint(readUint(bytes, endian))
+		}
+	}
+	v.Set(result)
+	return nil
+}
+
+// elemWidthToBytes writes length integers of width, selected by elem_width_from field. Missing elements
+// are written as zeros
+func (e *Encoder) elemWidthToBytes(parent, v reflect.Value, length int, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	width := ft.elemWidth(parent, v.Type().Elem())
+	for i := 0; i < length; i++ {
+		var err error
+		if i >= v.Len() {
+			buffer.Write(make([]byte, width))
+		} else if isSigned(v.Index(i).Kind()) {
+			err = e.putInt(v.Index(i).Int(), width, ft, buffer, endian)
+		} else {
+			err = e.putUint(v.Index(i).Uint(), width, ft, buffer, endian)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "can't convert element %d to bytes", i)
+		}
+	}
+	return nil
+}
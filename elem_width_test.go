@@ -0,0 +1,62 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestElemWidthFrom(t *testing.T) {
+	Convey("Test elem_width_from option", t, func() {
+		type Samples struct {
+			WidthFlag uint8
+			Count     uint8
+			Values    []int32 `d2b:"length_from:Count,elem_width_from:WidthFlag"`
+		}
+		Convey("Should decode 2-byte elements if flag is 0", func() {
+			wire := []byte{0, 3, 0x00, 0x01, 0xff, 0xfe, 0x7f, 0xff}
+			var samples Samples
+			err := Decode(wire, binary.BigEndian, &samples)
+			So(err, ShouldBeNil)
+			So(samples, ShouldResemble, Samples{WidthFlag: 0, Count: 3, Values: []int32{1, -2, 0x7fff}})
+			bytes, err := Encode(samples, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should decode 4-byte elements if flag is 1", func() {
+			wire := []byte{1, 2, 0x00, 0x01, 0x00, 0x00, 0xff, 0xff, 0xff, 0xfe}
+			var samples Samples
+			err := Decode(wire, binary.BigEndian, &samples)
+			So(err, ShouldBeNil)
+			So(samples, ShouldResemble, Samples{WidthFlag: 1, Count: 2, Values: []int32{0x10000, -2}})
+			bytes, err := Encode(samples, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error if elements count exceeds data length", func() {
+			type Wide struct {
+				WidthFlag uint8
+				Count     uint32
+				Values    []int32 `d2b:"length_from:Count,elem_width_from:WidthFlag"`
+			}
+			var samples Wide
+			err := Decode([]byte{1, 0x7f, 0xff, 0xff, 0xff, 0, 1}, binary.BigEndian, &samples)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if value doesn't fit narrow elements", func() {
+			encoder := NewEncoder(binary.BigEndian)
+			encoder.ErrorOnOverflow = true
+			_, err := encoder.Encode(Samples{Count: 1, Values: []int32{0x10000}})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for non-integer elements", func() {
+			type Bad struct {
+				WidthFlag uint8
+				Values    []float32 `d2b:"length:2,elem_width_from:WidthFlag"`
+			}
+			_, err := Encode(Bad{}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
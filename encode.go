@@ -8,144 +8,564 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Encoder converts data to bytes
+type Encoder struct {
+	// TagKey is a key of struct tags with encoding options. DefaultTagKey is used if it's empty
+	TagKey string
+	// ErrorOnOverflow makes encoder return error, if integer value doesn't fit width of its field,
+	// instead of clamping or wrapping it around
+	ErrorOnOverflow bool
+	// Version is a version of data format. Fields with since/until options, which don't match it, are skipped
+	Version int
+	// NormalizeNaN makes encoder write all float NaN values with the same canonical bit pattern
+	NormalizeNaN bool
+	// RoundMode is a rounding of floats, stored as integers with scale or mantissa_exp options.
+	// RoundNearest is used if it's empty
+	RoundMode string
+	// HMACKey is a key, used to compute fields with hmac option
+	HMACKey []byte
+
+	endian binary.ByteOrder
+}
+
+// NewEncoder returns encoder, which writes data with given byte order
+func NewEncoder(endian binary.ByteOrder) *Encoder {
+	return &Encoder{endian: endian}
+}
+
 // Encode converts interface type to bytes array
 func Encode(data interface{}, endian binary.ByteOrder) ([]byte, error) {
+	return NewEncoder(endian).Encode(data)
+}
+
+// Encode converts interface type to bytes array
+func (e *Encoder) Encode(data interface{}) ([]byte, error) {
 	buffer := bytes.NewBuffer(nil)
-	err := valueToBytes(reflect.ValueOf(data), buffer, endian)
+	err := e.valueToBytes(reflect.ValueOf(data), buffer, e.endian)
 	if err != nil {
 		return nil, err
 	}
 	return buffer.Bytes(), nil
 }
 
+// ErrShortBuffer is returned by EncodeTo, if encoded data doesn't fit destination buffer.
+// It may be wrapped, use errors.Cause to compare
+var ErrShortBuffer = errors.New("d2b: short buffer")
+
+// EncodeTo converts data to bytes, written to dst, and returns count of written bytes
+func EncodeTo(dst []byte, data interface{}, endian binary.ByteOrder) (int, error) {
+	return NewEncoder(endian).EncodeTo(dst, data)
+}
+
+// EncodeTo converts data to bytes, written to dst, and returns count of written bytes. Bytes after
+// len(dst) are never written. If data doesn't fit dst, ErrShortBuffer is returned
+func (e *Encoder) EncodeTo(dst []byte, data interface{}) (int, error) {
+	// buffer writes to dst until its capacity is exceeded
+	buffer := bytes.NewBuffer(dst[:0:len(dst)])
+	err := e.valueToBytes(reflect.ValueOf(data), buffer, e.endian)
+	if err != nil {
+		return 0, err
+	}
+	if buffer.Len() > len(dst) {
+		return 0, errors.Wrapf(ErrShortBuffer, "need %d bytes, have %d", buffer.Len(), len(dst))
+	}
+	return copy(dst, buffer.Bytes()), nil
+}
+
+func (e *Encoder) getStructTags(structType reflect.Type) ([]*structFieldTag, error) {
+	return getStructTags(structType, e.TagKey)
+}
+
 // getTypeBytesLength returns reflect.Type's bytes representation
-func valueToBytes(v reflect.Value, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+func (e *Encoder) valueToBytes(v reflect.Value, buffer *bytes.Buffer, endian binary.ByteOrder) error {
 	kind := v.Kind()
 	t := v.Type()
+	endian = typeEndian(t, endian)
 	switch kind {
 	case reflect.Ptr:
 		if v.IsNil() {
-			typeLen, err := getTypeBytesLength(v.Type().Elem())
+			typeLen, err := e.getTypeBytesLength(v.Type().Elem())
 			if err != nil {
 				return err
 			}
 			buffer.Write(make([]byte, typeLen))
 			return nil
 		}
-		return valueToBytes(v.Elem(), buffer, endian)
+		return e.valueToBytes(v.Elem(), buffer, endian)
 	case reflect.Struct:
-		tags, err := getStructTags(t)
+		info, err := getStructInfo(t, e.TagKey)
 		if err != nil {
 			return errors.Wrapf(err, "parsing %v struct tags error", t.Name())
 		}
-		for i := 0; i < v.NumField(); i++ {
+		tags := info.tags
+		start := buffer.Len()
+		unionStart := buffer.Len()
+		// total_length field is patched after all fields are written
+		totalStart, totalEnd := -1, -1
+		echoStart, echoEnd := -1, -1
+		// offsets of fields, covered by checksums
+		var ranges [][2]int
+		if info.checksums {
+			ranges = make([][2]int, len(tags))
+		}
+		for position, i := range info.order {
 			ft := t.Field(i)
-			err := structFieldValueToBytes(v.Field(i), tags[i], buffer, endian)
+			if tags[i].OmitEmpty && emptyTrailer(v, tags, info.order[position:]) {
+				break
+			}
+			if tags[i].EndianFrom != "" {
+				endian = flagEndian(v.Field(tags[i].endianFromIndex))
+			}
+			if !tags[i].present(e.Version) {
+				continue
+			}
+			if tags[i].BOM {
+				unionStart = buffer.Len()
+				bomToBytes(buffer, endian)
+			} else if tags[i].CRC32 {
+				unionStart = buffer.Len()
+				crcEndian := endian
+				if tags[i].Endian != nil {
+					crcEndian = tags[i].Endian
+				}
+				if tags[i].CRCOver != "" {
+					// checksum is patched after covered field is written
+					buffer.Write(make([]byte, 4))
+				} else {
+					from, to := tags[i].crcRange(ranges, start, unionStart)
+					crcToBytes(buffer, from, to, crcEndian)
+				}
+			} else if tags[i].HMAC != "" {
+				unionStart = buffer.Len()
+				err = e.hmacToBytes(buffer, tags[i], start)
+			} else if tags[i].Range != "" {
+				unionStart = start + tags[i].rangeFrom
+				err = e.rangeFieldToBytes(v, v.Field(i), tags[i], buffer, unionStart, endian)
+			} else if tags[i].Union {
+				err = e.unionFieldToBytes(v, v.Field(i), tags[i], buffer, unionStart, endian)
+			} else {
+				unionStart = buffer.Len()
+				err = e.structFieldValueToBytes(v, v.Field(i), tags[i], buffer, endian)
+			}
 			if err != nil {
 				return errors.Wrapf(err, "can't encode %v.%v field to bytes", t.Name(), ft.Name)
 			}
+			if tags[i].TotalLength {
+				totalStart, totalEnd = unionStart, buffer.Len()
+			}
+			if tags[i].EchoLength != "" {
+				echoStart, echoEnd = unionStart, buffer.Len()
+			}
+			if ranges != nil {
+				ranges[i] = [2]int{unionStart, buffer.Len()}
+			}
+			if j := crcOverField(tags, i); j != -1 && tags[j].present(e.Version) {
+				crcEndian := endian
+				if tags[j].Endian != nil {
+					crcEndian = tags[j].Endian
+				}
+				patchCRC(buffer, ranges[j][0], unionStart, buffer.Len(), crcEndian)
+			}
+		}
+		if fixedSize := structFixedSize(tags); fixedSize != 0 {
+			written := buffer.Len() - start
+			if written > fixedSize {
+				return errors.Errorf("%s fields take %d bytes, which exceeds fixed size %d", t.Name(), written, fixedSize)
+			}
+			buffer.Write(make([]byte, fixedSize-written))
+		}
+		if i := totalLengthField(tags); i != -1 {
+			fieldEndian := endian
+			if tags[i].Endian != nil {
+				fieldEndian = tags[i].Endian
+			}
+			err = patchTotalLength(buffer, totalStart, totalEnd, buffer.Len()-start, fieldEndian)
+			if err != nil {
+				return errors.Wrapf(err, "can't patch %v.%v field", t.Name(), t.Field(i).Name)
+			}
+		}
+		if i := echoLengthField(tags); i != -1 && echoStart != -1 {
+			fieldEndian := endian
+			if tags[i].Endian != nil {
+				fieldEndian = tags[i].Endian
+			}
+			err = patchTotalLength(buffer, echoStart, echoEnd, buffer.Len()-start, fieldEndian)
+			return errors.Wrapf(err, "can't patch %v.%v field", t.Name(), t.Field(i).Name)
 		}
 		return nil
 	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Bool:
-		return binary.Write(buffer, endian, v.Interface())
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return binary.Write(buffer, endian, e.normalizeNaN(v).Interface())
 	case reflect.Array:
 		for i := 0; i < v.Len(); i++ {
-			err := valueToBytes(v.Index(i), buffer, endian)
+			err := e.valueToBytes(v.Index(i), buffer, endian)
 			if err != nil {
 				return errors.Wrap(err, "can't convert array element to bytes")
 			}
 		}
 		return nil
 	}
-	return errors.New("unsupported type: " + kind.String())
+	return unsupportedKindError(kind)
 }
-func structFieldValueToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
-	if ft.Skip {
+func (e *Encoder) structFieldValueToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if ft.Skip || ft.CurOffset || ft.RawSelf {
 		return nil
 	}
+	if ft.Endian != nil {
+		endian = ft.Endian
+	}
+	if ft.Fn != "" {
+		return e.fnToBytes(parent, ft, buffer, endian)
+	}
+	if ft.Transform != "" {
+		return transformToBytes(v, ft, buffer)
+	}
+	if ft.BitReverse {
+		return e.bitReversedToBytes(parent, v, ft, buffer, endian)
+	}
+	if ft.Compress != "" {
+		return e.compressedToBytes(parent, v, ft, buffer, endian)
+	}
+	if ft.Decimal != 0 {
+		return decimalToBytes(v, buffer, endian)
+	}
+	if isBitField(v.Type(), ft) {
+		return e.bitFieldsToBytes(parent, ft, buffer)
+	}
+	if ft.blank {
+		length, err := e.getStructFieldTypeBytesLength(v.Type(), ft)
+		if err != nil {
+			return err
+		}
+		buffer.Write(make([]byte, length))
+		return nil
+	}
+	endian = typeEndian(v.Type(), endian)
 	k := v.Kind()
 	switch k {
 	case reflect.Ptr:
 		if v.IsNil() {
-			typeLen, err := getStructFieldTypeBytesLength(v.Type().Elem(), ft)
+			// zero value is encoded, because string/slice length may depend on other fields
+			return e.structFieldValueToBytes(parent, reflect.Zero(v.Type().Elem()), ft, buffer, endian)
+		}
+		return e.structFieldValueToBytes(parent, v.Elem(), ft, buffer, endian)
+	case reflect.String:
+		if ft.LengthASCII != 0 {
+			return asciiLengthToBytes(v, ft, buffer)
+		}
+		if ft.Enum != nil {
+			value, err := enumValue(ft.Enum, v.String())
 			if err != nil {
 				return err
 			}
-			buffer.Write(make([]byte, typeLen))
+			b := make([]byte, ft.width(1))
+			putUint(b, endian, uint64(value))
+			buffer.Write(b)
 			return nil
 		}
-		return structFieldValueToBytes(v.Elem(), ft, buffer, endian)
-	case reflect.String:
-		if ft.Length == 0 {
+		if ft.PString != 0 {
+			return pstringToBytes(v, ft, buffer, endian)
+		}
+		if !ft.hasLength() {
 			return errors.New("need to specify length")
 		}
+		length, err := ft.length(parent)
+		if err != nil {
+			return err
+		}
 		val := v.String()
-		b := make([]byte, ft.Length)
-		copy(b, val)
+		b := make([]byte, length)
+		n := copy(b, val)
+		if ft.Pad != "" {
+			for i := n; i < length; i++ {
+				b[i] = ft.Pad[0]
+			}
+		}
+		if ft.ASCII7 != "" {
+			err = setParityBits(b, ft.ASCII7)
+			if err != nil {
+				return err
+			}
+		}
 		buffer.Write(b)
 	case reflect.Slice:
-		if ft.Length == 0 {
+		if ft.LengthASCII != 0 {
+			return asciiLengthToBytes(v, ft, buffer)
+		}
+		if ft.Bits != 0 {
+			return e.bitsToBytes(v, ft, buffer)
+		}
+		if ft.RLE != 0 && ft.BytePrefix == 0 {
+			return e.rleToBytes(parent, v, ft, buffer, endian)
+		}
+		if ft.Rest {
+			return e.restToBytes(v, ft, buffer, endian)
+		}
+		if ft.Terminator != "" {
+			return e.terminatedToBytes(v, ft, buffer, endian)
+		}
+		if ft.CountPrefix != 0 {
+			return e.countPrefixedToBytes(v, ft, buffer, endian)
+		}
+		if ft.countExpr != nil {
+			// count is derived from data length on decode
+			return e.restToBytes(v, ft, buffer, endian)
+		}
+		if ft.BytePrefix != 0 {
+			return e.bytePrefixedToBytes(parent, v, ft, buffer, endian)
+		}
+		if !ft.hasLength() {
 			return errors.New("need to specify length")
 		}
+		if ft.Pairs != "" {
+			return e.pairsToBytes(parent, v, ft, buffer, endian)
+		}
+		if ft.Interleave != 0 {
+			return e.interleavedToBytes(parent, v, ft, buffer, endian)
+		}
+		length, err := ft.length(parent)
+		if err != nil {
+			return err
+		}
+		err = ft.checkCount(length)
+		if err != nil {
+			return err
+		}
+		if ft.Nibbles {
+			return e.nibblesToBytes(v, length, buffer)
+		}
+		if ft.WidthFrom != "" {
+			return e.elemWidthToBytes(parent, v, length, ft, buffer, endian)
+		}
 
 		var l = v.Len()
-		var handleLength = ft.Length
+		var handleLength = length
 		if l < handleLength {
 			handleLength = l
 		}
 		for i := 0; i < handleLength; i++ {
-			err := valueToBytes(v.Index(i), buffer, endian)
+			err := e.elementToBytes(v.Index(i), ft, buffer, ft.elemEndian(i, endian))
 			if err != nil {
 				return errors.Wrap(err, "can't convert slice element to bytes")
 			}
 		}
-		if handleLength < ft.Length {
-			typeLen, err := getTypeBytesLength(v.Type().Elem())
+		if handleLength < length {
+			typeLen, err := e.getTypeBytesLength(v.Type().Elem())
 			if err != nil {
 				return errors.Wrap(err, "can't calculate slice element type length")
 			}
-			placeholder := make([]byte, typeLen*(ft.Length-handleLength))
+			if ft.Stride != 0 {
+				typeLen = ft.Stride
+			}
+			placeholder := make([]byte, typeLen*(length-handleLength))
 			buffer.Write(placeholder)
 		}
 
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return e.numberFieldToBytes(v, ft, buffer, endian)
+	case reflect.Struct:
+		if ft.NullFlag {
+			return e.nullableToBytes(v, buffer, endian)
+		}
+		if ft.Flags {
+			flagsToBytes(v, ft, buffer)
+			return nil
+		}
+		return e.valueToBytes(v, buffer, endian)
 	case reflect.Array:
+		if ft.Bitmap {
+			bitmapToBytes(v, ft, buffer)
+			return nil
+		}
+		if ft.GUID != "" {
+			b, err := guidBytes(v, ft.GUID)
+			if err != nil {
+				return err
+			}
+			buffer.Write(b)
+			return nil
+		}
 		for i := 0; i < v.Len(); i++ {
-			err := valueToBytes(v.Index(i), buffer, endian)
+			err := e.valueToBytes(v.Index(i), buffer, ft.elemEndian(i, endian))
 			if err != nil {
 				return errors.Wrap(err, "can't convert array element to bytes")
 			}
 		}
+	case reflect.Interface:
+		if ft.TypeNameFrom != "" {
+			return e.namedInterfaceToBytes(parent, v, ft, buffer, endian)
+		}
+		if ft.TypeIDFrom != "" {
+			return e.selectedInterfaceToBytes(parent, v, ft, buffer, endian)
+		}
+		if ft.TypeID == 0 {
+			return errors.New("need to specify typeid width")
+		}
+		return e.interfaceToBytes(v, ft.TypeID, buffer, endian)
+	case reflect.Map:
+		return e.mapToBytes(v, ft, buffer, endian)
 	default:
-		return valueToBytes(v, buffer, endian)
+		return e.valueToBytes(v, buffer, endian)
+	}
+	return nil
+}
+
+// unionFieldToBytes writes union field over the bytes of previous fields, starting at unionStart.
+// Zero values don't overwrite previous fields bytes, so the last non-zero field wins
+func (e *Encoder) unionFieldToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, unionStart int, endian binary.ByteOrder) error {
+	fieldBuffer := bytes.NewBuffer(nil)
+	err := e.structFieldValueToBytes(parent, v, ft, fieldBuffer, endian)
+	if err != nil {
+		return err
+	}
+	b := fieldBuffer.Bytes()
+	if end := unionStart + len(b); end > buffer.Len() {
+		buffer.Write(make([]byte, end-buffer.Len()))
+	}
+	if !isZero(v) {
+		copy(buffer.Bytes()[unionStart:], b)
 	}
 	return nil
 }
 
+// rangeFieldToBytes writes field over the bytes of its range, starting at rangeStart. Buffer is padded
+// with zeros, if it ends before the range
+func (e *Encoder) rangeFieldToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, rangeStart int, endian binary.ByteOrder) error {
+	fieldBuffer := bytes.NewBuffer(nil)
+	err := e.structFieldValueToBytes(parent, v, ft, fieldBuffer, endian)
+	if err != nil {
+		return err
+	}
+	b := fieldBuffer.Bytes()
+	err = ft.checkRangeSize(len(b))
+	if err != nil {
+		return err
+	}
+	if end := rangeStart + len(b); end > buffer.Len() {
+		buffer.Write(make([]byte, end-buffer.Len()))
+	}
+	copy(buffer.Bytes()[rangeStart:], b)
+	return nil
+}
+
+// restToBytes writes all slice elements
+func (e *Encoder) restToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	err := ft.checkCount(v.Len())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		err := e.valueToBytes(v.Index(i), buffer, endian)
+		if err != nil {
+			return errors.Wrap(err, "can't convert slice element to bytes")
+		}
+	}
+	return nil
+}
+
+// interfaceToBytes writes registered id of interface value type and the value itself
+func (e *Encoder) interfaceToBytes(v reflect.Value, idWidth int, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if v.IsNil() {
+		return errors.New("can't encode nil interface")
+	}
+	v = v.Elem()
+	id, ok := getRegisteredID(v.Type())
+	if !ok {
+		return errors.Errorf("type %v is not registered", v.Type())
+	}
+	if idWidth < 8 && id >= uint64(1)<<uint(8*idWidth) {
+		return errors.Errorf("type id %d doesn't fit %d-byte prefix", id, idWidth)
+	}
+	b := make([]byte, idWidth)
+	putUint(b, endian, id)
+	buffer.Write(b)
+	return e.valueToBytes(v, buffer, endian)
+}
+
+// namedInterfaceToBytes writes interface value, checking that its registered type name equals to
+// the value of typename_from field
+func (e *Encoder) namedInterfaceToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if v.IsNil() {
+		return errors.New("can't encode nil interface")
+	}
+	v = v.Elem()
+	name, ok := getRegisteredName(v.Type())
+	if !ok {
+		return errors.Errorf("type %v is not registered", v.Type())
+	}
+	if fieldName := parent.Field(ft.typeNameFromIndex).String(); fieldName != name {
+		return errors.Errorf("type %v is registered with name %q, but %s field contains %q", v.Type(), name, ft.TypeNameFrom, fieldName)
+	}
+	return e.valueToBytes(v, buffer, endian)
+}
+
+// selectedInterfaceToBytes writes value of interface field, which type id is stored in typeid_from field
+func (e *Encoder) selectedInterfaceToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if v.IsNil() {
+		return errors.New("can't encode nil interface")
+	}
+	v = v.Elem()
+	id, ok := getRegisteredID(v.Type())
+	if !ok {
+		return errors.Errorf("type %v is not registered", v.Type())
+	}
+	if fieldID := ft.typeID(parent); fieldID != id {
+		return errors.Errorf("type %v is registered with id %d, but %s field contains %d", v.Type(), id, ft.TypeIDFrom, fieldID)
+	}
+	return e.valueToBytes(v, buffer, endian)
+}
+
 // getTypeBytesLength returns reflect.Type's length in bytes
-func getTypeBytesLength(t reflect.Type) (int, error) {
+func (e *Encoder) getTypeBytesLength(t reflect.Type) (int, error) {
 	kind := t.Kind()
 	switch kind {
 	case reflect.Ptr:
-		return getTypeBytesLength(t.Elem())
+		return e.getTypeBytesLength(t.Elem())
 	case reflect.Struct:
 		var result int
-		tags, err := getStructTags(t)
+		info, err := getStructInfo(t, e.TagKey)
 		if err != nil {
 			return 0, errors.Wrapf(err, "parsing %v struct tags error", t.Name())
 		}
-		for i := 0; i < t.NumField(); i++ {
+		tags := info.tags
+		var unionLen int
+		for _, i := range info.order {
 			ft := t.Field(i)
-			fl, err := getStructFieldTypeBytesLength(ft.Type, tags[i])
+			if !tags[i].present(e.Version) {
+				continue
+			}
+			fl, err := e.getStructFieldTypeBytesLength(ft.Type, tags[i])
 			if err != nil {
 				return 0, errors.Wrapf(err, "detecting %v.%v field length error", t.Name(), ft.Name)
 			}
-
-			result += fl
+			if tags[i].Range != "" {
+				result += unionLen
+				unionLen = 0
+				if tags[i].rangeTo > result {
+					result = tags[i].rangeTo
+				}
+				continue
+			}
+			if !tags[i].Union {
+				result += unionLen
+				unionLen = 0
+			}
+			if fl > unionLen {
+				unionLen = fl
+			}
+		}
+		result += unionLen
+		if fixedSize := structFixedSize(tags); fixedSize != 0 {
+			if result > fixedSize {
+				return 0, errors.Errorf("%s fields take %d bytes, which exceeds fixed size %d", t.Name(), result, fixedSize)
+			}
+			return fixedSize, nil
 		}
 		return result, nil
-	case reflect.Int8, reflect.Uint8:
+	case reflect.Int8, reflect.Uint8, reflect.Bool:
 		return 1, nil
 	case reflect.Int16, reflect.Uint16:
 		return 2, nil
@@ -154,7 +574,7 @@ func getTypeBytesLength(t reflect.Type) (int, error) {
 	case reflect.Int64, reflect.Uint64, reflect.Float64:
 		return 8, nil
 	case reflect.Array:
-		elLen, err := getTypeBytesLength(t.Elem())
+		elLen, err := e.getTypeBytesLength(t.Elem())
 		if err != nil {
 			return 0, errors.Wrap(err, "detecting array element type length error")
 		}
@@ -164,33 +584,140 @@ func getTypeBytesLength(t reflect.Type) (int, error) {
 }
 
 // getTypeBytesLength returns reflect.Type's length in bytes, relying on struct tag
-func getStructFieldTypeBytesLength(r reflect.Type, tagInfo *structFieldTag) (int, error) {
-	if tagInfo.Skip {
+func (e *Encoder) getStructFieldTypeBytesLength(r reflect.Type, tagInfo *structFieldTag) (int, error) {
+	if tagInfo.Skip || tagInfo.CurOffset || tagInfo.RawSelf {
 		return 0, nil
 	}
+	if tagInfo.Fn != "" {
+		return 0, errors.Errorf("length of field, encoded with Encode%s method, depends on its value", tagInfo.Fn)
+	}
+	if tagInfo.Transform != "" {
+		return 0, errors.Errorf("length of field, encoded with %s transform, depends on its value", tagInfo.Transform)
+	}
+	if tagInfo.Compress != "" {
+		return 0, errors.New("length of compressed field depends on its value")
+	}
+	if tagInfo.Decimal != 0 {
+		return tagInfo.Decimal / 8, nil
+	}
 	switch r.Kind() {
 	case reflect.Ptr:
-		return getStructFieldTypeBytesLength(r.Elem(), tagInfo)
+		return e.getStructFieldTypeBytesLength(r.Elem(), tagInfo)
+	case reflect.Map:
+		// zero map has no entries
+		return tagInfo.CountPrefix, nil
 	case reflect.Slice:
-		if tagInfo.Length == 0 {
+		if tagInfo.Bits != 0 {
+			return bitsLength(tagInfo.Bits), nil
+		}
+		if tagInfo.Rest || tagInfo.countExpr != nil {
+			// zero slice has no elements
+			return 0, nil
+		}
+		if tagInfo.CountPrefix != 0 {
+			return tagInfo.CountPrefix, nil
+		}
+		if tagInfo.Terminator != "" {
+			// zero slice contains only terminator
+			return e.getTypeBytesLength(r.Elem())
+		}
+		if tagInfo.BytePrefix != 0 {
+			return tagInfo.BytePrefix, nil
+		}
+		if tagInfo.RLE != 0 {
+			// zero slice has no runs
+			return 0, nil
+		}
+		if tagInfo.LengthASCII != 0 {
+			return tagInfo.LengthASCII, nil
+		}
+		if !tagInfo.hasLength() {
 			return 0, errors.New("need to specify length")
 		}
-		elemLength, err := getTypeBytesLength(r.Elem())
+		if tagInfo.Nibbles {
+			return (tagInfo.Length + 1) / 2, nil
+		}
+		if tagInfo.Pairs != "" {
+			pairLen, err := e.getPairBytesLength(r, tagInfo.pairsType)
+			return tagInfo.Length * pairLen, err
+		}
+		if tagInfo.Interleave != 0 {
+			groupLen, err := e.getInterleavedGroupLength(tagInfo)
+			return tagInfo.Length * groupLen, err
+		}
+		if tagInfo.Stride != 0 {
+			return tagInfo.Length * tagInfo.Stride, nil
+		}
+		if tagInfo.WidthFrom != "" {
+			// width flag of zero struct selects narrow elements
+			return tagInfo.Length * int(r.Elem().Size()) / 2, nil
+		}
+		elemLength, err := e.getTypeBytesLength(r.Elem())
 		if err != nil {
 			return 0, errors.Wrap(err, "can't detect slice element length")
 		}
+		// length_from field of zero struct is zero too
 		return tagInfo.Length * elemLength, nil
 	case reflect.Array:
-		elemLength, err := getTypeBytesLength(r.Elem())
+		if tagInfo.Bitmap {
+			return bitsLength(r.Len()), nil
+		}
+		elemLength, err := e.getTypeBytesLength(r.Elem())
 		if err != nil {
 			return 0, errors.Wrap(err, "can't detect array element length")
 		}
 		return r.Len() * elemLength, nil
 	case reflect.String:
-		if tagInfo.Length == 0 {
+		if tagInfo.Enum != nil {
+			return tagInfo.width(1), nil
+		}
+		if tagInfo.PString != 0 {
+			// zero string has no bytes after prefix
+			return tagInfo.PString, nil
+		}
+		if tagInfo.LengthASCII != 0 {
+			return tagInfo.LengthASCII, nil
+		}
+		if !tagInfo.hasLength() {
 			return 0, errors.New("need to specify length")
 		}
 		return tagInfo.Length, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if tagInfo.ASCII {
+			return tagInfo.Length, nil
+		}
+		if tagInfo.Bits != 0 {
+			return tagInfo.bitGroupLen, nil
+		}
+		if tagInfo.Varint != "" {
+			// zero takes one byte
+			return 1, nil
+		}
+		if tagInfo.Duration != 0 {
+			return tagInfo.width(8), nil
+		}
+		if tagInfo.Width != 0 {
+			return tagInfo.Width, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if tagInfo.ASCII {
+			return tagInfo.Length, nil
+		}
+		if tagInfo.Mantissa != 0 {
+			return tagInfo.Mantissa + tagInfo.Exponent, nil
+		}
+		if tagInfo.scaled() {
+			return tagInfo.width(int(r.Size())), nil
+		}
+	case reflect.Struct:
+		if tagInfo.NullFlag {
+			// zero nullable value is not valid, so only flag is written
+			return 1, nil
+		}
+		if tagInfo.Flags {
+			return bitsLength(r.NumField()), nil
+		}
 	}
-	return getTypeBytesLength(r)
+	return e.getTypeBytesLength(r)
 }
@@ -4,9 +4,16 @@ import (
 	"encoding/binary"
 	"testing"
 
+	"github.com/pkg/errors"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
+type testTriangle struct {
+	Base uint8
+}
+
+func (t testTriangle) Area() int32 { return int32(t.Base) * int32(t.Base) / 2 }
+
 func TestEncode(t *testing.T) {
 	Convey("Test Encode", t, func() {
 		type CustomType [5][2]int16
@@ -59,6 +66,139 @@ func TestEncode(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(bytes, ShouldHaveLength, 83)
 		})
+		Convey("Should encode string and slice with length from previous field", func() {
+			type Struct struct {
+				SLen uint8
+				S    string `d2b:"length_from:SLen"`
+				ALen uint8
+				A    *[]int16 `d2b:"length_from:ALen"`
+			}
+			bytes, err := Encode(Struct{SLen: 2, S: "abc", ALen: 2}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{2, 'a', 'b', 2, 0, 0, 0, 0})
+		})
+		Convey("Should encode floats", func() {
+			bytes, err := Encode(struct {
+				A float32
+				B float64
+			}{A: 1, B: -2}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x3f, 0x80, 0, 0, 0xc0, 0, 0, 0, 0, 0, 0, 0})
+		})
+		Convey("Should encode interface fields with registered type id", func() {
+			type Struct struct {
+				A testShape  `d2b:"typeid:u8"`
+				B *testShape `d2b:"typeid:u16"`
+			}
+			var b testShape = &testRect{W: 2, H: 5}
+			bytes, err := Encode(Struct{A: testSquare{Side: 3}, B: &b}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 3, 0, 0, 0, 2, 0, 2, 0, 5, 0})
+
+			_, err = Encode(Struct{A: testSquare{Side: 3}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if registered type id doesn't fit its width", func() {
+			RegisterType(300, testTriangle{})
+			type Struct struct {
+				A testShape `d2b:"typeid:u8"`
+			}
+			_, err := Encode(Struct{A: testTriangle{Base: 2}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should encode guid in ms and rfc4122 layouts", func() {
+			type GUID [16]byte
+			type Struct struct {
+				MS  GUID `d2b:"guid:ms"`
+				RFC GUID `d2b:"guid:rfc4122"`
+			}
+			// {00112233-4455-6677-8899-aabbccddeeff}
+			guid := GUID{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+			bytes, err := Encode(Struct{MS: guid, RFC: guid}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{
+				0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+				0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+			})
+		})
+		Convey("Should apply field endian to all array elements", func() {
+			type Struct struct {
+				A [8]uint16 `d2b:"repeat:8,endian:big"`
+				B uint16
+			}
+			bytes, err := Encode(Struct{A: [8]uint16{1, 2, 3, 4, 5, 6, 7, 8}, B: 1}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 1, 0, 2, 0, 3, 0, 4, 0, 5, 0, 6, 0, 7, 0, 8, 1, 0})
+		})
+		Convey("Should apply field endian to all nested struct fields", func() {
+			type Leaf struct {
+				Z uint16
+			}
+			type Inner struct {
+				Y    uint32
+				Leaf Leaf
+				Back uint16 `d2b:"endian:big"`
+			}
+			type Middle struct {
+				X     uint16
+				Inner *Inner
+				Arr   [2]uint16
+				Slice []Leaf `d2b:"length:1"`
+			}
+			type Outer struct {
+				A   uint16
+				Mid Middle `d2b:"endian:little"`
+				B   uint32
+			}
+			value := Outer{
+				A: 1,
+				Mid: Middle{
+					X:     2,
+					Inner: &Inner{Y: 3, Leaf: Leaf{Z: 4}, Back: 5},
+					Arr:   [2]uint16{6, 7},
+					Slice: []Leaf{{Z: 8}},
+				},
+				B: 9,
+			}
+			data := []byte{
+				0, 1,
+				2, 0, 3, 0, 0, 0, 4, 0, 0, 5, 6, 0, 7, 0, 8, 0,
+				0, 0, 0, 9,
+			}
+			bytes, err := Encode(value, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, data)
+
+			var decoded Outer
+			err = Decode(data, binary.BigEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, value)
+		})
+		Convey("Should read options from custom tag key", func() {
+			type Struct struct {
+				Len  uint8
+				Name string `wire:"length_from:Len" d2b:"-"`
+				B    uint16 `wire:"endian:big"`
+			}
+			encoder := NewEncoder(binary.LittleEndian)
+			encoder.TagKey = "wire"
+			bytes, err := encoder.Encode(Struct{Len: 2, Name: "hi", B: 1})
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{2, 'h', 'i', 0, 1})
+
+			bytes, err = Encode(Struct{Len: 2, Name: "hi", B: 1}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{2, 1, 0})
+		})
+		Convey("Should encode all elements of rest slice", func() {
+			type Struct struct {
+				Header  uint8
+				Records []uint16 `d2b:"rest:true"`
+			}
+			bytes, err := Encode(Struct{Header: 1, Records: []uint16{2, 3}}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 2, 0, 3, 0})
+		})
 		Convey("Should return error if struct tag length contains wrong value", func() {
 			type ErrTestStruct struct {
 				Field string `d2b:"length:1qwe"`
@@ -189,3 +329,36 @@ func TestEncode(t *testing.T) {
 		})
 	})
 }
+
+func TestEncodeTo(t *testing.T) {
+	Convey("Test EncodeTo", t, func() {
+		type Message struct {
+			ID      uint16
+			Payload []byte `d2b:"byte_prefix:u8"`
+		}
+		message := Message{ID: 0x0102, Payload: []byte{1, 2, 3}}
+		expected := []byte{0x01, 0x02, 3, 1, 2, 3}
+		Convey("Should encode into exactly fitting buffer", func() {
+			dst := make([]byte, 6)
+			n, err := EncodeTo(dst, message, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 6)
+			So(dst, ShouldResemble, expected)
+		})
+		Convey("Should leave rest of oversized buffer untouched", func() {
+			dst := []byte{9, 9, 9, 9, 9, 9, 9, 9}
+			n, err := EncodeTo(dst, message, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 6)
+			So(dst, ShouldResemble, append(expected, 9, 9))
+		})
+		Convey("Should return ErrShortBuffer for undersized buffer", func() {
+			backing := []byte{9, 9, 9, 9, 9, 9, 9, 9}
+			dst := backing[:4]
+			n, err := EncodeTo(dst, message, binary.BigEndian)
+			So(errors.Cause(err), ShouldEqual, ErrShortBuffer)
+			So(n, ShouldEqual, 0)
+			So(backing[4:], ShouldResemble, []byte{9, 9, 9, 9})
+		})
+	})
+}
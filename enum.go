@@ -0,0 +1,84 @@
+package d2b
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type enumEntry struct {
+	Name  string
+	Value int64
+}
+
+// parseEnum parses enum entries, separated with "|": NAME=VALUE or just VALUE
+func parseEnum(s string) ([]enumEntry, error) {
+	var result []enumEntry
+	for _, part := range strings.Split(s, "|") {
+		var entry enumEntry
+		value := part
+		if i := strings.Index(part, "="); i != -1 {
+			entry.Name, value = part[:i], part[i+1:]
+		}
+		var err error
+		entry.Value, err = strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad enum entry %q", part)
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// checkEnumWidth checks, that values of string enum entries fit unsigned integer of given width
+func checkEnumWidth(t reflect.Type, entries []enumEntry, width int) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Value < 0 || width < 8 && entry.Value >= int64(1)<<uint(8*width) {
+			return errors.Errorf("enum value %d of %s doesn't fit %d byte(s)", entry.Value, entry.Name, width)
+		}
+	}
+	return nil
+}
+
+// enumName returns name of enum entry with given value
+func enumName(entries []enumEntry, value int64) (string, error) {
+	for _, entry := range entries {
+		if entry.Value == value {
+			return entry.Name, nil
+		}
+	}
+	return "", errors.Errorf("unknown enum value %d", value)
+}
+
+// enumValue returns value of enum entry with given name
+func enumValue(entries []enumEntry, name string) (int64, error) {
+	for _, entry := range entries {
+		if entry.Name == name {
+			return entry.Value, nil
+		}
+	}
+	return 0, errors.Errorf("unknown enum name %q", name)
+}
+
+// checkEnum checks, that integer value v is one of enum values
+func checkEnum(entries []enumEntry, v reflect.Value) error {
+	var value int64
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = v.Int()
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = int64(v.Uint())
+	default:
+		return errors.Errorf("enum field should be integer or string, not %v", v.Type())
+	}
+	_, err := enumName(entries, value)
+	return err
+}
@@ -0,0 +1,95 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEnum(t *testing.T) {
+	Convey("Test enum fields", t, func() {
+		type Struct struct {
+			Color string `d2b:"enum:RED=1|GREEN=2|BLUE=4"`
+			Mode  string `d2b:"enum:OFF=0|ON=0x100,width:2"`
+			Level uint8  `d2b:"enum:1|2|3"`
+		}
+		Convey("Should decode known values to names", func() {
+			var result Struct
+			err := Decode([]byte{2, 0x00, 0x01, 3}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{Color: "GREEN", Mode: "ON", Level: 3})
+		})
+		Convey("Should return error for unknown values", func() {
+			var result Struct
+			err := Decode([]byte{3, 0x00, 0x01, 3}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "unknown enum value 3")
+
+			err = Decode([]byte{4, 0x00, 0x01, 5}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should encode names to values", func() {
+			bytes, err := Encode(Struct{Color: "BLUE", Mode: "OFF", Level: 1}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{4, 0, 0, 1})
+		})
+		Convey("Should return error for unknown names", func() {
+			_, err := Encode(Struct{Color: "PINK", Mode: "OFF", Level: 1}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Struct{Color: "RED", Mode: "OFF", Level: 7}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if enum values don't fit width", func() {
+			type Wide struct {
+				Color string `d2b:"enum:A=1|B=300,width:1"`
+			}
+			_, err := Encode(Wide{Color: "A"}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			So(Decode([]byte{1}, binary.LittleEndian, &Wide{}), ShouldNotBeNil)
+
+			type Negative struct {
+				Color string `d2b:"enum:A=1|B=-1"`
+			}
+			_, err = Encode(Negative{Color: "A"}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+type testColor uint8
+
+type testTemperature int16
+
+func TestNamedIntegerTypes(t *testing.T) {
+	Convey("Test fields of named integer types", t, func() {
+		type Struct struct {
+			Color   testColor `d2b:"enum:1|2|3"`
+			Free    testColor
+			Temp    testTemperature
+			Colors  [2]testColor
+			Pointer *testTemperature `d2b:"endian:big"`
+		}
+		temp := testTemperature(-2)
+		data := Struct{Color: 2, Free: 200, Temp: -300, Colors: [2]testColor{3, 4}, Pointer: &temp}
+		wire := []byte{2, 200, 0xd4, 0xfe, 3, 4, 0xff, 0xfe}
+		Convey("Should decode named integer types", func() {
+			var result Struct
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should encode named integer types", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should validate enum values of named integer types", func() {
+			var result Struct
+			err := Decode([]byte{7, 200, 0xd4, 0xfe, 3, 4, 0xff, 0xfe}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Struct{Color: 7}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
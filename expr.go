@@ -0,0 +1,172 @@
+package d2b
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// exprTotal is a name of expression variable, which contains count of decoded data bytes
+const exprTotal = "total"
+
+// expr is a node of integer arithmetic expression, e.g. "(total - 8) / 4". Names refer to total or
+// previous integer fields of the struct
+type expr struct {
+	op          byte
+	value       int64
+	name        string
+	left, right *expr
+
+	fieldIndex int
+}
+
+// parseExpr parses expression of integers, names, parentheses and + - * / % operators
+func parseExpr(s string) (*expr, error) {
+	p := &exprParser{s: s}
+	result, err := p.parseSum()
+	if err != nil {
+		return nil, errors.Wrapf(err, "bad expression %q", s)
+	}
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return nil, errors.Errorf("bad expression %q: unexpected %q", s, p.s[p.pos:])
+	}
+	return result, nil
+}
+
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// parseSum parses terms, separated by + and -
+func (p *exprParser) parseSum() (*expr, error) {
+	left, err := p.parseProduct()
+	for err == nil {
+		p.skipSpaces()
+		if p.pos == len(p.s) || p.s[p.pos] != '+' && p.s[p.pos] != '-' {
+			return left, nil
+		}
+		op := p.s[p.pos]
+		p.pos++
+		var right *expr
+		right, err = p.parseProduct()
+		left = &expr{op: op, left: left, right: right}
+	}
+	return nil, err
+}
+
+// parseProduct parses operands, separated by *, / and %
+func (p *exprParser) parseProduct() (*expr, error) {
+	left, err := p.parseOperand()
+	for err == nil {
+		p.skipSpaces()
+		if p.pos == len(p.s) || !strings.ContainsRune("*/%", rune(p.s[p.pos])) {
+			return left, nil
+		}
+		op := p.s[p.pos]
+		p.pos++
+		var right *expr
+		right, err = p.parseOperand()
+		left = &expr{op: op, left: left, right: right}
+	}
+	return nil, err
+}
+
+// parseOperand parses integer, name or expression in parentheses
+func (p *exprParser) parseOperand() (*expr, error) {
+	p.skipSpaces()
+	if p.pos == len(p.s) {
+		return nil, errors.New("unexpected end")
+	}
+	if p.s[p.pos] == '(' {
+		p.pos++
+		result, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.pos == len(p.s) || p.s[p.pos] != ')' {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return result, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] == '_' || isAlnum(p.s[p.pos])) {
+		p.pos++
+	}
+	token := p.s[start:p.pos]
+	if token == "" {
+		return nil, errors.Errorf("unexpected %q", p.s[start:])
+	}
+	if token[0] >= '0' && token[0] <= '9' {
+		value, err := strconv.ParseInt(token, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &expr{value: value}, nil
+	}
+	return &expr{name: token}, nil
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// resolve checks, that names of expression refer to total or previous integer fields
+func (x *expr) resolve(structType reflect.Type, previous []int) (err error) {
+	switch {
+	case x.op != 0:
+		err = x.left.resolve(structType, previous)
+		if err == nil {
+			err = x.right.resolve(structType, previous)
+		}
+	case x.name != "" && x.name != exprTotal:
+		x.fieldIndex, err = resolveIntegerField(structType, previous, "expression", x.name)
+	}
+	return err
+}
+
+// eval returns value of expression. Names are taken from parent struct fields
+func (x *expr) eval(parent reflect.Value, total int) (int64, error) {
+	switch {
+	case x.op == 0 && x.name == exprTotal:
+		return int64(total), nil
+	case x.op == 0 && x.name != "":
+		return int64(uintValue(parent.Field(x.fieldIndex))), nil
+	case x.op == 0:
+		return x.value, nil
+	}
+	left, err := x.left.eval(parent, total)
+	if err != nil {
+		return 0, err
+	}
+	right, err := x.right.eval(parent, total)
+	if err != nil {
+		return 0, err
+	}
+	switch x.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	}
+	if right == 0 {
+		return 0, errors.New("division by zero")
+	}
+	if x.op == '/' {
+		return left / right, nil
+	}
+	return left % right, nil
+}
@@ -0,0 +1,105 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DecodeField decodes bytes to single field of struct, which data points to, applying field's tags.
+// Nested fields are addressed with dot separated path, e.g. "Header.Flags". It returns count of consumed bytes
+func DecodeField(bytes []byte, endian binary.ByteOrder, data interface{}, path string) (int, error) {
+	d := NewDecoder(bytes, endian)
+	err := d.DecodeField(data, path)
+	return d.offset, err
+}
+
+// fieldStep is a struct field on the path to decoded field
+type fieldStep struct {
+	structType reflect.Type
+	index      int
+	tags       []*structFieldTag
+	order      []int
+}
+
+// DecodeField reads next bytes to single field of struct, which data points to. See DecodeField function.
+// Byte orders of parent structs and fields, registered ones and endian_from fields are applied to the field.
+// Field, which isn't present in decoder version, or which parent isn't present, is set to zero
+func (d *Decoder) DecodeField(data interface{}, path string) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr {
+		return errors.New("data should be pointer")
+	}
+	if v.IsNil() {
+		return errors.New("can't decode to nil pointer")
+	}
+	steps, err := resolveFieldPath(v.Type(), path, d.TagKey)
+	if err != nil {
+		return err
+	}
+	endian := d.endian
+	for i, step := range steps {
+		for v.Kind() == reflect.Ptr {
+			endian = typeEndian(v.Type(), endian)
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		endian = typeEndian(step.structType, endian)
+		for _, j := range step.order {
+			if step.tags[j].EndianFrom != "" {
+				endian = flagEndian(v.Field(step.tags[j].endianFromIndex))
+			}
+			if j == step.index {
+				break
+			}
+		}
+		parent, tags := v, step.tags[step.index]
+		v = v.Field(step.index)
+		if !tags.present(d.Version) {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if i < len(steps)-1 {
+			if tags.Endian != nil {
+				endian = tags.Endian
+			}
+			continue
+		}
+		offset := d.offset
+		err = d.decodeStructField(parent, v, tags, endian)
+		if err != nil {
+			d.offset = offset
+			return errors.Wrapf(err, "can't update struct field %s", path)
+		}
+	}
+	return nil
+}
+
+// resolveFieldPath returns struct fields on dot separated path from type t
+func resolveFieldPath(t reflect.Type, path string, tagKey string) ([]fieldStep, error) {
+	names := strings.Split(path, ".")
+	steps := make([]fieldStep, len(names))
+	for i, name := range names {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, errors.Errorf("%s is not a struct", strings.Join(names[:i], "."))
+		}
+		field, ok := t.FieldByName(name)
+		if !ok || len(field.Index) != 1 {
+			return nil, errors.Errorf("%v has no field %s", t, name)
+		}
+		info, err := getStructInfo(t, tagKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't parse struct tags")
+		}
+		steps[i] = fieldStep{structType: t, index: field.Index[0], tags: info.tags, order: info.order}
+		t = field.Type
+	}
+	return steps, nil
+}
@@ -0,0 +1,22 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/pkg/errors"
+)
+
+// Fingerprint returns FNV-1a hash of data, encoded in big endian byte order with normalized NaNs. Equal values
+// have equal fingerprints, no matter which byte order they were decoded with
+func Fingerprint(data interface{}) (uint64, error) {
+	encoder := NewEncoder(binary.BigEndian)
+	encoder.NormalizeNaN = true
+	bytes, err := encoder.Encode(data)
+	if err != nil {
+		return 0, errors.Wrap(err, "can't encode data")
+	}
+	h := fnv.New64a()
+	h.Write(bytes)
+	return h.Sum64(), nil
+}
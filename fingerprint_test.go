@@ -0,0 +1,41 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFingerprint(t *testing.T) {
+	Convey("Test Fingerprint", t, func() {
+		type Record struct {
+			ID    uint32
+			Value int16
+			Name  string `d2b:"length:4"`
+		}
+		Convey("Should return equal fingerprints for equal values, decoded with different byte orders", func() {
+			var little, big Record
+			err := Decode([]byte{1, 2, 3, 4, 5, 6, 'a', 'b', 'c', 'd'}, binary.LittleEndian, &little)
+			So(err, ShouldBeNil)
+			err = Decode([]byte{4, 3, 2, 1, 6, 5, 'a', 'b', 'c', 'd'}, binary.BigEndian, &big)
+			So(err, ShouldBeNil)
+			a, err := Fingerprint(little)
+			So(err, ShouldBeNil)
+			b, err := Fingerprint(&big)
+			So(err, ShouldBeNil)
+			So(a, ShouldEqual, b)
+		})
+		Convey("Should return different fingerprints for different values", func() {
+			a, err := Fingerprint(Record{ID: 1, Name: "abcd"})
+			So(err, ShouldBeNil)
+			b, err := Fingerprint(Record{ID: 2, Name: "abcd"})
+			So(err, ShouldBeNil)
+			So(a, ShouldNotEqual, b)
+		})
+		Convey("Should return error for values, which can't be encoded", func() {
+			_, err := Fingerprint(struct{ A string }{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
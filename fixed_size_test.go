@@ -0,0 +1,143 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFixedSize(t *testing.T) {
+	Convey("Test structs with fixed size", t, func() {
+		type Block struct {
+			_    struct{} `d2b:"fixed_size:8"`
+			ID   uint16
+			Name string `d2b:"length:3"`
+		}
+		type File struct {
+			Blocks [2]Block
+			Tail   uint8
+		}
+		wire := []byte{
+			1, 0, 'a', 'b', 'c', 0, 0, 0,
+			2, 0, 'd', 0, 0, 0, 0, 0,
+			9,
+		}
+		data := File{Blocks: [2]Block{{ID: 1, Name: "abc"}, {ID: 2, Name: "d"}}, Tail: 9}
+		Convey("Should skip padding on decode", func() {
+			var result File
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should write padding on encode", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+
+			var empty *File
+			bytes, err = Encode(empty, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldHaveLength, 17)
+		})
+		Convey("Should return error if fields exceed fixed size", func() {
+			type Big struct {
+				_ struct{} `d2b:"fixed_size:2"`
+				A uint32
+			}
+			_, err := Encode(Big{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			So(Decode([]byte{1, 2, 3, 4}, binary.LittleEndian, &Big{}), ShouldNotBeNil)
+		})
+		Convey("Should return error if fixed_size is declared on named field", func() {
+			type Bad struct {
+				A uint32 `d2b:"fixed_size:8"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestReserved(t *testing.T) {
+	Convey("Test reserved regions", t, func() {
+		type Block struct {
+			_  struct{} `d2b:"fixed_size:4"`
+			ID uint8
+		}
+		type Struct struct {
+			A      uint8
+			_      [2]byte
+			Block  Block
+			Values []uint8 `d2b:"length:2,stride:2"`
+		}
+		wire := []byte{1, 0xaa, 0xbb, 7, 0, 0xcc, 0, 5, 0, 6, 0xdd}
+		Convey("Should skip blank fields and write zeros for them", func() {
+			var result Struct
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.A, ShouldEqual, 1)
+			So(result.Block.ID, ShouldEqual, 7)
+			So(result.Values, ShouldResemble, []uint8{5, 6})
+
+			bytes, err := Encode(result, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 0, 0, 7, 0, 0, 0, 5, 0, 6, 0})
+		})
+		Convey("Should capture reserved bytes with CaptureReserved", func() {
+			var result Struct
+			decoder := NewDecoder(wire, binary.LittleEndian)
+			decoder.CaptureReserved = true
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(decoder.Reserved(), ShouldResemble, []ReservedRegion{
+				{Offset: 1, Bytes: []byte{0xaa, 0xbb}},
+				{Offset: 4, Bytes: []byte{0, 0xcc, 0}},
+				{Offset: 8, Bytes: []byte{0}},
+				{Offset: 10, Bytes: []byte{0xdd}},
+			})
+		})
+		Convey("Should accumulate reserved bytes of successful Decode calls only", func() {
+			decoder := NewDecoder(append(append([]byte{}, wire...), wire[:5]...), binary.LittleEndian)
+			decoder.CaptureReserved = true
+			So(decoder.Decode(&Struct{}), ShouldBeNil)
+			So(decoder.Decode(&Struct{}), ShouldNotBeNil)
+			So(decoder.Reserved(), ShouldHaveLength, 4)
+		})
+		Convey("Should not capture reserved bytes by default", func() {
+			decoder := NewDecoder(wire, binary.LittleEndian)
+			So(decoder.Decode(&Struct{}), ShouldBeNil)
+			So(decoder.Reserved(), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestPacked(t *testing.T) {
+	Convey("Test packed structs", t, func() {
+		type Packed struct {
+			_ struct{} `d2b:"packed:true"`
+			A uint8
+			B uint32
+			C uint16
+			D uint64
+		}
+		Convey("Should not add padding between fields", func() {
+			data := Packed{A: 1, B: 2, C: 3, D: 4}
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 2, 0, 0, 0, 3, 0, 4, 0, 0, 0, 0, 0, 0, 0})
+			plans, err := Plan(data)
+			So(err, ShouldBeNil)
+			So(plans[len(plans)-1].Offset, ShouldEqual, 7)
+			var result Packed
+			So(Decode(bytes, binary.LittleEndian, &result), ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should return error if packed is declared on regular field", func() {
+			type Bad struct {
+				A uint8 `d2b:"packed:true"`
+			}
+			So(Decode([]byte{1}, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
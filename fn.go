@@ -0,0 +1,78 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Fields with fn:Name tag option are decoded and encoded by methods of the parent struct:
+//
+//	func (s *Struct) DecodeName(bytes []byte, endian binary.ByteOrder) (int, error)
+//	func (s *Struct) EncodeName(endian binary.ByteOrder) ([]byte, error)
+//
+// Decode method receives all bytes, which are left, and returns count of consumed ones.
+// Encode method returns bytes of the field, their count may vary
+
+// fnMethod returns method of struct value or pointer to it
+func fnMethod(parent reflect.Value, name string) (reflect.Value, error) {
+	if method := parent.MethodByName(name); method.IsValid() {
+		return method, nil
+	}
+	if !parent.CanAddr() {
+		ptr := reflect.New(parent.Type())
+		ptr.Elem().Set(parent)
+		parent = ptr.Elem()
+	}
+	if method := parent.Addr().MethodByName(name); method.IsValid() {
+		return method, nil
+	}
+	return reflect.Value{}, errors.Errorf("%v has no %s method", parent.Type(), name)
+}
+
+// decodeFn decodes field with parent struct's Decode<fn> method
+func (d *Decoder) decodeFn(parent reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	if d.reader != nil {
+		return errors.New("fields with fn option can't be decoded from reader")
+	}
+	method, err := fnMethod(parent, "Decode"+tags.Fn)
+	if err != nil {
+		return err
+	}
+	decode, ok := method.Interface().(func([]byte, binary.ByteOrder) (int, error))
+	if !ok {
+		return errors.Errorf("Decode%s method should be func([]byte, binary.ByteOrder) (int, error)", tags.Fn)
+	}
+	start := d.offset
+	rest, _ := d.next(d.length() - d.offset)
+	d.offset = start
+	n, err := decode(rest, endian)
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > len(rest) {
+		return errors.Errorf("Decode%s method consumed %d bytes of %d", tags.Fn, n, len(rest))
+	}
+	d.offset += n
+	return nil
+}
+
+// fnToBytes encodes field with parent struct's Encode<fn> method
+func (e *Encoder) fnToBytes(parent reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	method, err := fnMethod(parent, "Encode"+ft.Fn)
+	if err != nil {
+		return err
+	}
+	encode, ok := method.Interface().(func(binary.ByteOrder) ([]byte, error))
+	if !ok {
+		return errors.Errorf("Encode%s method should be func(binary.ByteOrder) ([]byte, error)", ft.Fn)
+	}
+	b, err := encode(endian)
+	if err != nil {
+		return err
+	}
+	buffer.Write(b)
+	return nil
+}
@@ -0,0 +1,64 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testFnStruct struct {
+	Kind  uint8
+	Value uint64 `d2b:"fn:Value"`
+	Tail  uint8
+}
+
+// DecodeValue decodes Value as LEB128
+func (s *testFnStruct) DecodeValue(bytes []byte, endian binary.ByteOrder) (int, error) {
+	value, n := binary.Uvarint(bytes)
+	if n <= 0 {
+		return 0, errors.New("bad varint")
+	}
+	s.Value = value
+	return n, nil
+}
+
+// EncodeValue encodes Value as LEB128
+func (s testFnStruct) EncodeValue(endian binary.ByteOrder) ([]byte, error) {
+	b := make([]byte, binary.MaxVarintLen64)
+	return b[:binary.PutUvarint(b, s.Value)], nil
+}
+
+func TestFn(t *testing.T) {
+	Convey("Test fields with fn option", t, func() {
+		Convey("Should encode field with variable bytes count", func() {
+			bytes, err := Encode(testFnStruct{Kind: 1, Value: 1, Tail: 2}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 1, 2})
+
+			bytes, err = Encode(&testFnStruct{Kind: 1, Value: 300, Tail: 2}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 0xac, 0x02, 2})
+		})
+		Convey("Should decode field with method, which reports consumed bytes", func() {
+			var result testFnStruct
+			err := Decode([]byte{1, 0xac, 0x02, 2}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, testFnStruct{Kind: 1, Value: 300, Tail: 2})
+		})
+		Convey("Should return error if method returns error", func() {
+			var result testFnStruct
+			err := Decode([]byte{1, 0xac}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if method is missing", func() {
+			type Bad struct {
+				A uint8 `d2b:"fn:Missing"`
+			}
+			So(Decode([]byte{1}, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package d2b
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// GUIDLayoutRFC4122 stores all GUID fields in big-endian order
+	GUIDLayoutRFC4122 = "rfc4122"
+	// GUIDLayoutMS stores first three GUID fields in little-endian order, as Microsoft does
+	GUIDLayoutMS = "ms"
+)
+
+var guidType = reflect.TypeOf([16]byte{})
+
+// swapGUID converts GUID bytes between rfc4122 and ms layouts
+func swapGUID(b []byte) {
+	b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+	b[4], b[5] = b[5], b[4]
+	b[6], b[7] = b[7], b[6]
+}
+
+// guidBytes converts [16]byte value in rfc4122 layout to bytes in given layout
+func guidBytes(v reflect.Value, layout string) ([]byte, error) {
+	if !v.Type().ConvertibleTo(guidType) {
+		return nil, errors.Errorf("guid field should be [16]byte, not %v", v.Type())
+	}
+	guid := v.Convert(guidType).Interface().([16]byte)
+	if layout == GUIDLayoutMS {
+		swapGUID(guid[:])
+	}
+	return guid[:], nil
+}
+
+// setGUID sets [16]byte value v in rfc4122 layout from bytes in given layout
+func setGUID(v reflect.Value, bytes []byte, layout string) error {
+	if !v.Type().ConvertibleTo(guidType) {
+		return errors.Errorf("guid field should be [16]byte, not %v", v.Type())
+	}
+	var guid [16]byte
+	copy(guid[:], bytes)
+	if layout == GUIDLayoutMS {
+		swapGUID(guid[:])
+	}
+	v.Set(reflect.ValueOf(guid).Convert(v.Type()))
+	return nil
+}
@@ -1,10 +1,112 @@
 package d2b
 
-func bytesToStr(bytes []byte) string {
+import (
+	"encoding/binary"
+	"reflect"
+	"unsafe"
+)
+
+const maxInt = int(^uint(0) >> 1)
+
+// bytesToStr returns string from bytes, terminated by zero byte. See toStr
+func (d *Decoder) bytesToStr(bytes []byte) string {
 	for key, value := range bytes {
 		if value == '\u0000' {
-			return string(bytes[:key])
+			return d.toStr(bytes[:key])
+		}
+	}
+	return d.toStr(bytes)
+}
+
+// toStr converts bytes to string. If InternStrings option is set, equal strings share one copy. Otherwise,
+// if zero copy strings are allowed, string shares memory with bytes, so bytes must not be changed while
+// string is used
+func (d *Decoder) toStr(bytes []byte) string {
+	if d.InternStrings {
+		if s, ok := d.interned[string(bytes)]; ok {
+			return s
+		}
+		if d.interned == nil {
+			d.interned = make(map[string]string)
+		}
+		s := string(bytes)
+		d.interned[s] = s
+		return s
+	}
+	if d.zeroCopyStrings() {
+		return *(*string)(unsafe.Pointer(&bytes))
+	}
+	return string(bytes)
+}
+
+// readUint reads unsigned integer, which width equals to len(bytes)
+func readUint(bytes []byte, endian binary.ByteOrder) uint64 {
+	switch len(bytes) {
+	case 1:
+		return uint64(bytes[0])
+	case 2:
+		return uint64(endian.Uint16(bytes))
+	case 4:
+		return uint64(endian.Uint32(bytes))
+	}
+	return endian.Uint64(bytes)
+}
+
+// readInt reads signed integer, which width equals to len(bytes)
+func readInt(bytes []byte, endian binary.ByteOrder) int64 {
+	shift := uint(64 - 8*len(bytes))
+	return int64(readUint(bytes, endian)<<shift) >> shift
+}
+
+// putUint writes unsigned integer, which width equals to len(bytes)
+func putUint(bytes []byte, endian binary.ByteOrder, value uint64) {
+	switch len(bytes) {
+	case 1:
+		bytes[0] = byte(value)
+	case 2:
+		endian.PutUint16(bytes, uint16(value))
+	case 4:
+		endian.PutUint32(bytes, uint32(value))
+	default:
+		endian.PutUint64(bytes, value)
+	}
+}
+
+// isZero returns true if v is zero value of its type
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// uintValue returns value of integer v as unsigned one. Nil pointers are treated as zero
+func uintValue(v reflect.Value) uint64 {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0
+		}
+		v = v.Elem()
+	}
+	if isSigned(v.Kind()) {
+		return uint64(v.Int())
+	}
+	return v.Uint()
+}
+
+// zeroTrailer sets struct fields with given indexes, which were omitted with omit_empty option, to zero
+func zeroTrailer(v reflect.Value, tags []*structFieldTag, fields []int) {
+	for _, i := range fields {
+		if !tags[i].Skip && !tags[i].blank {
+			v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+		}
+	}
+}
+
+// emptyTrailer returns true if struct fields with given indexes are zero, so omit_empty fields can be omitted.
+// Skipped and blank fields are ignored
+func emptyTrailer(v reflect.Value, tags []*structFieldTag, fields []int) bool {
+	for _, i := range fields {
+		if !tags[i].Skip && !tags[i].blank && !isZero(v.Field(i)) {
+			return false
 		}
 	}
-	return string(bytes[:])
+	return true
 }
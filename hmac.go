@@ -0,0 +1,88 @@
+package d2b
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrAuthFailed is returned by decoder, if hmac field doesn't match HMAC of the preceding bytes.
+// It may be wrapped, use errors.Cause to compare
+var ErrAuthFailed = errors.New("d2b: message authentication failed")
+
+// hmacHashes contains hash functions, supported by hmac option
+var hmacHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// checkHMACType checks, that field with hmac option is byte array of the hash size
+func checkHMACType(t reflect.Type, name string) error {
+	newHash, ok := hmacHashes[name]
+	if !ok {
+		return errors.Errorf("unknown hmac hash %q", name)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	size := newHash().Size()
+	if t.Kind() != reflect.Array || t.Elem().Kind() != reflect.Uint8 || t.Len() != size {
+		return errors.Errorf("hmac:%s field should be [%d]byte, not %v", name, size, t)
+	}
+	return nil
+}
+
+// computeHMAC returns HMAC of b with given hash and key
+func computeHMAC(name string, key, b []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("hmac key is not set")
+	}
+	mac := hmac.New(hmacHashes[name], key)
+	mac.Write(b)
+	return mac.Sum(nil), nil
+}
+
+// checkHMAC checks, that value of hmac field v matches HMAC of bytes at [start:end). It returns
+// ErrAuthFailed if it doesn't
+func (d *Decoder) checkHMAC(v reflect.Value, tags *structFieldTag, start, end int) error {
+	if d.reader != nil {
+		return errors.New("hmac can't be checked in reader")
+	}
+	offset := d.offset
+	d.offset = start
+	b, err := d.next(end - start)
+	d.offset = offset
+	if err != nil {
+		return err
+	}
+	sum, err := computeHMAC(tags.HMAC, d.HMACKey, b)
+	if err != nil {
+		return err
+	}
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	value := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(value), v)
+	if !hmac.Equal(sum, value) {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// hmacToBytes writes HMAC of buffer bytes, starting at start
+func (e *Encoder) hmacToBytes(buffer *bytes.Buffer, tags *structFieldTag, start int) error {
+	sum, err := computeHMAC(tags.HMAC, e.HMACKey, buffer.Bytes()[start:])
+	if err != nil {
+		return err
+	}
+	buffer.Write(sum)
+	return nil
+}
@@ -0,0 +1,66 @@
+package d2b
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHMAC(t *testing.T) {
+	Convey("Test hmac option", t, func() {
+		type Message struct {
+			ID        uint16
+			Payload   []byte   `d2b:"length:3"`
+			Signature [32]byte `d2b:"hmac:sha256"`
+		}
+		key := []byte("secret")
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte{1, 0, 'a', 'b', 'c'})
+		var signature [32]byte
+		copy(signature[:], mac.Sum(nil))
+		data := append([]byte{1, 0, 'a', 'b', 'c'}, signature[:]...)
+		Convey("Should compute HMAC of preceding bytes on encoding", func() {
+			encoder := NewEncoder(binary.LittleEndian)
+			encoder.HMACKey = key
+			b, err := encoder.Encode(Message{ID: 1, Payload: []byte("abc")})
+			So(err, ShouldBeNil)
+			So(b, ShouldResemble, data)
+		})
+		Convey("Should decode valid message", func() {
+			decoder := NewDecoder(data, binary.LittleEndian)
+			decoder.HMACKey = key
+			var message Message
+			err := decoder.Decode(&message)
+			So(err, ShouldBeNil)
+			So(message, ShouldResemble, Message{ID: 1, Payload: []byte("abc"), Signature: signature})
+		})
+		Convey("Should return ErrAuthFailed for tampered message", func() {
+			tampered := append([]byte(nil), data...)
+			tampered[2] = 'x'
+			decoder := NewDecoder(tampered, binary.LittleEndian)
+			decoder.HMACKey = key
+			decoder.OnError = func(string, error) bool { return true }
+			var message Message
+			err := decoder.Decode(&message)
+			So(errors.Cause(err), ShouldEqual, ErrAuthFailed)
+		})
+		Convey("Should return error without key", func() {
+			var message Message
+			err := Decode(data, binary.LittleEndian, &message)
+			So(err, ShouldNotBeNil)
+			So(errors.Cause(err), ShouldNotEqual, ErrAuthFailed)
+		})
+		Convey("Should return error if hmac field isn't the last one", func() {
+			type Bad struct {
+				Signature [32]byte `d2b:"hmac:sha256"`
+				ID        uint16
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
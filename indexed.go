@@ -0,0 +1,54 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// IndexEntry is an entry of index table, which precedes records, decoded by DecodeIndexed
+type IndexEntry struct {
+	// Offset is a record offset from the beginning of data
+	Offset uint32
+	// Length is a count of record bytes
+	Length uint32
+}
+
+// DecodeIndexed reads index table of indexCount entries from the beginning of bytes and decodes records
+// of template's type, which are referenced by them. It returns pointers to records in the order of index
+func DecodeIndexed(bytes []byte, endian binary.ByteOrder, indexCount int, template interface{}) ([]interface{}, error) {
+	t := reflect.TypeOf(template)
+	if t == nil {
+		return nil, errors.New("template should not be nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// entry takes 8 bytes, so count, which doesn't fit data, is rejected before allocation
+	if indexCount < 0 || indexCount > len(bytes)/8 {
+		return nil, errors.Errorf("index of %d entries doesn't fit data with length %d", indexCount, len(bytes))
+	}
+	index := make([]IndexEntry, indexCount)
+	d := NewDecoder(bytes, endian)
+	for i := range index {
+		err := d.Decode(&index[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't decode index entry %d", i)
+		}
+	}
+	result := make([]interface{}, indexCount)
+	for i, entry := range index {
+		end := uint64(entry.Offset) + uint64(entry.Length)
+		if end > uint64(len(bytes)) {
+			return nil, errors.Errorf("record %d at [%d:%d] is out of data with length %d", i, entry.Offset, end, len(bytes))
+		}
+		record := reflect.New(t)
+		err := DecodeLimited(bytes[entry.Offset:], endian, record.Interface(), int(entry.Length))
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't decode record %d", i)
+		}
+		result[i] = record.Interface()
+	}
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeIndexed(t *testing.T) {
+	Convey("Test DecodeIndexed", t, func() {
+		type Record struct {
+			ID   uint8
+			Data []byte `d2b:"rest:true"`
+		}
+		data := []byte{
+			24, 0, 0, 0, 3, 0, 0, 0,
+			30, 0, 0, 0, 1, 0, 0, 0,
+			27, 0, 0, 0, 3, 0, 0, 0,
+			1, 'a', 'b',
+			3, 'c', 'd',
+			2,
+		}
+		Convey("Should decode records, referenced by index", func() {
+			records, err := DecodeIndexed(data, binary.LittleEndian, 3, Record{})
+			So(err, ShouldBeNil)
+			So(records, ShouldResemble, []interface{}{
+				&Record{ID: 1, Data: []byte("ab")},
+				&Record{ID: 2, Data: []byte{}},
+				&Record{ID: 3, Data: []byte("cd")},
+			})
+		})
+		Convey("Should return error if record is out of data", func() {
+			_, err := DecodeIndexed(data[:29], binary.LittleEndian, 3, Record{})
+			So(err, ShouldNotBeNil)
+			_, err = DecodeIndexed(data[:20], binary.LittleEndian, 3, Record{})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if index count doesn't fit data", func() {
+			_, err := DecodeIndexed(data, binary.LittleEndian, -1, Record{})
+			So(err, ShouldNotBeNil)
+			_, err = DecodeIndexed(data, binary.LittleEndian, 1<<30, Record{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
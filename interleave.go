@@ -0,0 +1,133 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// resolveInterleave checks, that interleave option of the first channel field is followed by other
+// channel slice fields, and marks them as skipped, because their elements are encoded and decoded
+// together with the first channel
+func resolveInterleave(structType reflect.Type, index int, tags []*structFieldTag) error {
+	tag := tags[index]
+	if tag.Interleave < 2 {
+		return errors.Errorf("interleave should declare at least 2 channels, not %d", tag.Interleave)
+	}
+	if index+tag.Interleave > structType.NumField() {
+		return errors.Errorf("interleave declares %d channels, but struct has no fields for them", tag.Interleave)
+	}
+	tag.interleaveIndex = index
+	tag.interleaveTypes = make([]reflect.Type, tag.Interleave)
+	for i := 0; i < tag.Interleave; i++ {
+		ft := structType.Field(index + i)
+		if ft.Type.Kind() != reflect.Slice {
+			return errors.Errorf("interleaved channel field %s should be slice", ft.Name)
+		}
+		tag.interleaveTypes[i] = ft.Type
+		if i != 0 {
+			tags[index+i].Skip = true
+		}
+	}
+	return nil
+}
+
+// decodeInterleaved decodes interleaved elements of channel slices
+func (d *Decoder) decodeInterleaved(parent reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	length, err := tags.length(parent)
+	if err != nil {
+		return err
+	}
+	err = tags.checkCount(length)
+	if err != nil {
+		return err
+	}
+	err = d.checkAllocation(uint64(length), d.channelGroupSize(tags.interleaveTypes))
+	if err != nil {
+		return err
+	}
+	channels := make([]reflect.Value, tags.Interleave)
+	for i, t := range tags.interleaveTypes {
+		channels[i] = d.makeSlice(t, length)
+	}
+	for i := 0; i < length; i++ {
+		for c, channel := range channels {
+			err = d.decodeValue(channel.Index(i), endian)
+			if err != nil {
+				return errors.Wrapf(err, "can't decode element %d of channel %d", i, c)
+			}
+		}
+	}
+	for i, channel := range channels {
+		parent.Field(tags.interleaveIndex + i).Set(channel)
+	}
+	return nil
+}
+
+// channelGroupSize returns the smallest count of bytes of one element of each channel. Element of unknown
+// size takes at least one byte
+func (d *Decoder) channelGroupSize(types []reflect.Type) int {
+	size := 0
+	for _, t := range types {
+		elemSize, err := (&Encoder{TagKey: d.TagKey, Version: d.Version}).getTypeBytesLength(t.Elem())
+		if err != nil || d.AllowTruncation {
+			elemSize = 1
+		}
+		size += elemSize
+	}
+	return size
+}
+
+// interleavedToBytes encodes elements of channel slices, starting from the first channel field v, in turn
+func (e *Encoder) interleavedToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	channels := make([]reflect.Value, ft.Interleave)
+	for i := range channels {
+		channels[i] = parent.Field(ft.interleaveIndex + i)
+		if channels[i].Len() != v.Len() {
+			return errors.Errorf("channel %d elements count %d doesn't match first channel count %d", i, channels[i].Len(), v.Len())
+		}
+	}
+	length, err := ft.length(parent)
+	if err != nil {
+		return err
+	}
+	err = ft.checkCount(length)
+	if err != nil {
+		return err
+	}
+	handleLength := v.Len()
+	if length < handleLength {
+		handleLength = length
+	}
+	for i := 0; i < handleLength; i++ {
+		for c, channel := range channels {
+			err = e.valueToBytes(channel.Index(i), buffer, endian)
+			if err != nil {
+				return errors.Wrapf(err, "can't convert element %d of channel %d to bytes", i, c)
+			}
+		}
+	}
+	if handleLength < length {
+		groupLen, err := e.getInterleavedGroupLength(ft)
+		if err != nil {
+			return err
+		}
+		buffer.Write(make([]byte, groupLen*(length-handleLength)))
+	}
+	return nil
+}
+
+// getInterleavedGroupLength returns length of one element of every channel
+func (e *Encoder) getInterleavedGroupLength(ft *structFieldTag) (int, error) {
+	var result int
+	for i, t := range ft.interleaveTypes {
+		l, err := e.getTypeBytesLength(t.Elem())
+		if err != nil {
+			return 0, errors.Wrapf(err, "can't detect channel %d element length", i)
+		}
+		result += l
+	}
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInterleave(t *testing.T) {
+	Convey("Test interleaved channels", t, func() {
+		type Stereo struct {
+			Count uint8
+			Left  []int16 `d2b:"length_from:Count,interleave:2"`
+			Right []int16
+			Tail  uint8
+		}
+		data := Stereo{Count: 3, Left: []int16{1, 2, 3}, Right: []int16{-1, -2, -3}, Tail: 9}
+		wire := []byte{3, 1, 0, 0xff, 0xff, 2, 0, 0xfe, 0xff, 3, 0, 0xfd, 0xff, 9}
+		Convey("Should deinterleave channels", func() {
+			var result Stereo
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should interleave channels", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error if channels count exceeds data length", func() {
+			type Wide struct {
+				Count uint32
+				Left  []int16 `d2b:"length_from:Count,interleave:2"`
+				Right []int16
+			}
+			var result Wide
+			err := Decode([]byte{0xff, 0xff, 0xff, 0x7f, 1, 2, 3, 4}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if channels lengths differ", func() {
+			_, err := Encode(Stereo{Count: 1, Left: []int16{1}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if there are no fields for channels", func() {
+			type Bad struct {
+				Left []int16 `d2b:"length:1,interleave:2"`
+			}
+			So(Decode(wire, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
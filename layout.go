@@ -0,0 +1,80 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// layoutField is a JSON description of one field, returned by LayoutJSON
+type layoutField struct {
+	Name       string `json:"name"`
+	Offset     int    `json:"offset"`
+	Size       int    `json:"size"`
+	Kind       string `json:"kind"`
+	Endian     string `json:"endian,omitempty"`
+	EndianFrom string `json:"endian_from,omitempty"`
+	Tags       string `json:"tags,omitempty"`
+}
+
+// LayoutJSON returns JSON array, which describes fields of data struct type in the order of Plan: their
+// dot separated names, offsets, sizes, kinds, declared byte orders or fields, which select them, and d2b tags.
+// Offset and size are -1, if they depend on data value
+func LayoutJSON(data interface{}) ([]byte, error) {
+	return NewEncoder(nil).LayoutJSON(data)
+}
+
+// LayoutJSON returns JSON description of fields like LayoutJSON function, reading struct tags with encoder's
+// TagKey
+func (e *Encoder) LayoutJSON(data interface{}) ([]byte, error) {
+	plans, err := e.Plan(data)
+	if err != nil {
+		return nil, err
+	}
+	t := reflect.TypeOf(data)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	tagKey := e.TagKey
+	if tagKey == "" {
+		tagKey = DefaultTagKey
+	}
+	layout := make([]layoutField, len(plans))
+	for i, plan := range plans {
+		layout[i] = layoutField{
+			Name:       plan.Path,
+			Offset:     plan.Offset,
+			Size:       plan.Size,
+			Kind:       plan.Kind.String(),
+			Endian:     endianName(plan.Endian),
+			EndianFrom: plan.EndianFrom,
+			Tags:       structFieldByPath(t, plan.Path).Tag.Get(tagKey),
+		}
+	}
+	return json.Marshal(layout)
+}
+
+// structFieldByPath returns field of struct type t by dot separated path
+func structFieldByPath(t reflect.Type, path string) reflect.StructField {
+	var field reflect.StructField
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		field, _ = t.FieldByName(name)
+		t = field.Type
+	}
+	return field
+}
+
+// endianName returns name of byte order, used in endian tag option, or empty string for nil
+func endianName(endian binary.ByteOrder) string {
+	switch endian {
+	case binary.BigEndian:
+		return "big"
+	case binary.LittleEndian:
+		return "little"
+	}
+	return ""
+}
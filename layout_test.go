@@ -0,0 +1,54 @@
+package d2b
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLayoutJSON(t *testing.T) {
+	Convey("Test LayoutJSON", t, func() {
+		type Header struct {
+			Version uint8
+			Flags   uint16 `d2b:"endian:big"`
+		}
+		type Frame struct {
+			Header  Header
+			Len     uint8
+			Payload []byte `d2b:"length_from:Len"`
+		}
+		Convey("Should describe fields of struct", func() {
+			layout, err := LayoutJSON(&Frame{})
+			So(err, ShouldBeNil)
+			So(string(layout), ShouldEqual, `[`+
+				`{"name":"Header.Version","offset":0,"size":1,"kind":"uint8"},`+
+				`{"name":"Header.Flags","offset":1,"size":2,"kind":"uint16","endian":"big","tags":"endian:big"},`+
+				`{"name":"Len","offset":3,"size":1,"kind":"uint8"},`+
+				`{"name":"Payload","offset":4,"size":-1,"kind":"slice","tags":"length_from:Len"}`+
+				`]`)
+		})
+		Convey("Should describe fields with encoder's tag key", func() {
+			type Message struct {
+				Len  uint8
+				Text string `wire:"length_from:Len"`
+			}
+			encoder := NewEncoder(nil)
+			encoder.TagKey = "wire"
+			layout, err := encoder.LayoutJSON(Message{})
+			So(err, ShouldBeNil)
+			So(string(layout), ShouldEqual, `[`+
+				`{"name":"Len","offset":0,"size":1,"kind":"uint8"},`+
+				`{"name":"Text","offset":1,"size":-1,"kind":"string","tags":"length_from:Len"}`+
+				`]`)
+			size, err := encoder.Size(struct {
+				Name string `wire:"length:3"`
+			}{})
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, 3)
+		})
+		Convey("Should return error for non-struct values", func() {
+			_, err := LayoutJSON(1)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
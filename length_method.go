@@ -0,0 +1,39 @@
+package d2b
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Length of string/slice field with length_method:Name option is returned by the parent struct method:
+//
+//	func (s *Struct) Name() int
+//
+// Method is called after the previous fields are decoded, so it may combine their values
+
+// checkLengthMethod checks, that struct or pointer to it has length method with correct signature
+func checkLengthMethod(structType reflect.Type, name string) error {
+	method, ok := reflect.PtrTo(structType).MethodByName(name)
+	if !ok {
+		return errors.Errorf("%v has no %s method", structType, name)
+	}
+	t := method.Type
+	if t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Int {
+		return errors.Errorf("%s method should be func() int", name)
+	}
+	return nil
+}
+
+// methodLength returns length, computed by parent struct method
+func methodLength(parent reflect.Value, name string) (int, error) {
+	method, err := fnMethod(parent, name)
+	if err != nil {
+		return 0, err
+	}
+	length := int(method.Call(nil)[0].Int())
+	if length < 0 {
+		return 0, errors.Errorf("%s method returned negative length %d", name, length)
+	}
+	return length, nil
+}
@@ -0,0 +1,48 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testGrid struct {
+	Rows  uint8
+	Cols  uint8
+	Cells []uint8 `d2b:"length_method:CellCount"`
+	Name  string  `d2b:"length_method:NameLength"`
+}
+
+func (g *testGrid) CellCount() int {
+	return int(g.Rows) * int(g.Cols)
+}
+
+func (g *testGrid) NameLength() int {
+	return int(g.Rows) + 1
+}
+
+func TestLengthMethod(t *testing.T) {
+	Convey("Test length_method option", t, func() {
+		grid := testGrid{Rows: 2, Cols: 3, Cells: []uint8{1, 2, 3, 4, 5, 6}, Name: "abc"}
+		data := []byte{2, 3, 1, 2, 3, 4, 5, 6, 'a', 'b', 'c'}
+		Convey("Should encode field with length, computed by method", func() {
+			b, err := Encode(grid, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(b, ShouldResemble, data)
+		})
+		Convey("Should decode field with length, computed from previous fields", func() {
+			var decoded testGrid
+			err := Decode(data, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, grid)
+		})
+		Convey("Should return error if method doesn't exist", func() {
+			type Bad struct {
+				Data []byte `d2b:"length_method:Missing"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
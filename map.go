@@ -0,0 +1,173 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Map fields with count_prefix option are stored as entries count of declared width, followed by entries.
+// Every entry is a key, followed by a value. String and []byte values are prefixed with their length of width,
+// declared with value_prefix option. Encoder writes entries in order of their key bytes
+
+// decodeMap reads entries count and decodes that many map entries
+func (d *Decoder) decodeMap(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	if tags.CountPrefix == 0 {
+		return errors.New("need to specify count_prefix of map")
+	}
+	prefix, err := d.next(tags.CountPrefix)
+	if err != nil {
+		return err
+	}
+	count := readUint(prefix, endian)
+	// every entry takes at least one byte
+	err = d.checkAllocation(count, 1)
+	if err != nil {
+		return errors.Wrapf(err, "entries count %d exceeds data length", count)
+	}
+	err = tags.checkCount(int(count))
+	if err != nil {
+		return err
+	}
+	t := v.Type()
+	result := d.makeMap(t, int(count))
+	for i := 0; i < int(count); i++ {
+		key := reflect.New(t.Key()).Elem()
+		err = d.decodeValue(key, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't decode key of entry %d", i)
+		}
+		if result.MapIndex(key).IsValid() {
+			return errors.Errorf("duplicate key %v of entry %d", key.Interface(), i)
+		}
+		value := reflect.New(t.Elem()).Elem()
+		err = d.decodeMapValue(value, tags, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't decode value of entry %d", i)
+		}
+		result.SetMapIndex(key, value)
+	}
+	v.Set(result)
+	return nil
+}
+
+// decodeMapValue decodes map value. String and []byte values are read after their length prefix
+func (d *Decoder) decodeMapValue(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	if !isBytesOrString(v.Type()) {
+		return d.decodeValue(v, endian)
+	}
+	prefix, err := d.next(tags.ValuePrefix)
+	if err != nil {
+		return err
+	}
+	length := readUint(prefix, endian)
+	err = d.checkAllocation(length, 1)
+	if err != nil {
+		return errors.Wrapf(err, "value length %d exceeds data length", length)
+	}
+	b, err := d.next(int(length))
+	if err != nil {
+		return err
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(string(b))
+		return nil
+	}
+	result := d.makeSlice(v.Type(), len(b))
+	reflect.Copy(result, reflect.ValueOf(b))
+	v.Set(result)
+	return nil
+}
+
+// mapToBytes writes entries count of declared width, followed by map entries, sorted by key bytes
+func (e *Encoder) mapToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if ft.CountPrefix == 0 {
+		return errors.New("need to specify count_prefix of map")
+	}
+	count := v.Len()
+	err := ft.checkCount(count)
+	if err != nil {
+		return err
+	}
+	if ft.CountPrefix < 8 && uint64(count) >= uint64(1)<<uint(8*ft.CountPrefix) {
+		return errors.Errorf("entries count %d doesn't fit %d-byte prefix", count, ft.CountPrefix)
+	}
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+	entries := make([]entry, 0, count)
+	for _, key := range v.MapKeys() {
+		keyBuffer := bytes.NewBuffer(nil)
+		err = e.valueToBytes(key, keyBuffer, endian)
+		if err != nil {
+			return errors.Wrap(err, "can't convert map key to bytes")
+		}
+		entries = append(entries, entry{key: keyBuffer.Bytes(), value: v.MapIndex(key)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	prefix := make([]byte, ft.CountPrefix)
+	putUint(prefix, endian, uint64(count))
+	buffer.Write(prefix)
+	for _, entry := range entries {
+		buffer.Write(entry.key)
+		err = e.mapValueToBytes(entry.value, ft, buffer, endian)
+		if err != nil {
+			return errors.Wrap(err, "can't convert map value to bytes")
+		}
+	}
+	return nil
+}
+
+// mapValueToBytes writes map value. String and []byte values are preceded by their length prefix
+func (e *Encoder) mapValueToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if !isBytesOrString(v.Type()) {
+		return e.valueToBytes(v, buffer, endian)
+	}
+	length := v.Len()
+	if ft.ValuePrefix < 8 && uint64(length) >= uint64(1)<<uint(8*ft.ValuePrefix) {
+		return errors.Errorf("value length %d doesn't fit %d-byte prefix", length, ft.ValuePrefix)
+	}
+	prefix := make([]byte, ft.ValuePrefix)
+	putUint(prefix, endian, uint64(length))
+	buffer.Write(prefix)
+	if v.Kind() == reflect.String {
+		buffer.WriteString(v.String())
+		return nil
+	}
+	b := make([]byte, length)
+	reflect.Copy(reflect.ValueOf(b), v)
+	buffer.Write(b)
+	return nil
+}
+
+// checkMapType checks, that map field has count_prefix option and that its string or []byte values
+// have value_prefix option
+func checkMapType(t reflect.Type, tag *structFieldTag) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Map {
+		if tag.ValuePrefix != 0 {
+			return errors.Errorf("field with value_prefix option should be map, not %v", t)
+		}
+		return nil
+	}
+	if tag.CountPrefix == 0 {
+		return errors.New("need to specify count_prefix of map")
+	}
+	if isBytesOrString(t.Elem()) && tag.ValuePrefix == 0 {
+		return errors.New("need to specify value_prefix of map values")
+	}
+	return nil
+}
+
+// isBytesOrString returns true if t is string or slice of bytes
+func isBytesOrString(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
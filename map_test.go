@@ -0,0 +1,64 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMap(t *testing.T) {
+	Convey("Test maps with count_prefix option", t, func() {
+		type Dictionary struct {
+			Entries map[[4]byte][]byte `d2b:"count_prefix:u16,value_prefix:u8"`
+		}
+		dictionary := Dictionary{Entries: map[[4]byte][]byte{
+			{'n', 'a', 'm', 'e'}: []byte("box"),
+			{'s', 'i', 'z', 'e'}: {1, 2},
+			{'e', 'm', 'p', 't'}: {},
+		}}
+		data := []byte{
+			3, 0,
+			'e', 'm', 'p', 't', 0,
+			'n', 'a', 'm', 'e', 3, 'b', 'o', 'x',
+			's', 'i', 'z', 'e', 2, 1, 2,
+		}
+		Convey("Should encode entries, sorted by keys", func() {
+			b, err := Encode(dictionary, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(b, ShouldResemble, data)
+		})
+		Convey("Should decode entries", func() {
+			var decoded Dictionary
+			err := Decode(data, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, dictionary)
+		})
+		Convey("Should return error for duplicate keys", func() {
+			var decoded Dictionary
+			err := Decode([]byte{2, 0, 'a', 'a', 'a', 'a', 0, 'a', 'a', 'a', 'a', 0}, binary.LittleEndian, &decoded)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should limit counts, read from stream, with MaxStringLen", func() {
+			type Big struct {
+				Entries map[uint8][]byte `d2b:"count_prefix:u32,value_prefix:u32"`
+			}
+			var decoded Big
+			decoder := NewReaderDecoder(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0x7f, 1}), binary.LittleEndian)
+			So(decoder.Decode(&decoded), ShouldNotBeNil)
+			decoder = NewReaderDecoder(bytes.NewReader([]byte{1, 0, 0, 0, 7, 0xff, 0xff, 0xff, 0x7f, 1}), binary.LittleEndian)
+			So(decoder.Decode(&decoded), ShouldNotBeNil)
+			decoder = NewReaderDecoder(bytes.NewReader([]byte{1, 0, 0, 0, 7, 1, 0, 0, 0, 9}), binary.LittleEndian)
+			So(decoder.Decode(&decoded), ShouldBeNil)
+			So(decoded.Entries, ShouldResemble, map[uint8][]byte{7: {9}})
+		})
+		Convey("Should return error for map without value_prefix", func() {
+			type Bad struct {
+				Entries map[uint8]string `d2b:"count_prefix:u8"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,55 @@
+package d2b
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// decodeNibbles decodes count 4-bit elements to []uint8 field. Every byte contains two elements,
+// high nibble first. Odd count of elements ends with half of byte
+func (d *Decoder) decodeNibbles(v reflect.Value, count int) error {
+	b, err := d.next((count + 1) / 2)
+	if err != nil {
+		return err
+	}
+	result := d.makeSlice(v.Type(), count)
+	for i := 0; i < count; i++ {
+		nibble := b[i/2] >> 4
+		if i%2 == 1 {
+			nibble = b[i/2] & 0x0f
+		}
+		result.Index(i).SetUint(uint64(nibble))
+	}
+	v.Set(result)
+	return nil
+}
+
+// nibblesToBytes packs count first elements of []uint8 field as 4-bit values. Missing elements are zero
+func (e *Encoder) nibblesToBytes(v reflect.Value, count int, buffer *bytes.Buffer) error {
+	b := make([]byte, (count+1)/2)
+	for i := 0; i < count && i < v.Len(); i++ {
+		nibble := v.Index(i).Uint()
+		if nibble > 0x0f {
+			return errors.Errorf("element %d value %d doesn't fit nibble", i, nibble)
+		}
+		if i%2 == 0 {
+			nibble <<= 4
+		}
+		b[i/2] |= byte(nibble)
+	}
+	buffer.Write(b)
+	return nil
+}
+
+// checkNibbles checks, that field with nibbles option is []uint8
+func checkNibbles(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return errors.Errorf("nibbles field should be []uint8, not %v", t)
+	}
+	return nil
+}
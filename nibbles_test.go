@@ -0,0 +1,34 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNibbles(t *testing.T) {
+	Convey("Test nibble-packed fields", t, func() {
+		type Sprite struct {
+			Palette []uint8 `d2b:"nibbles:true,length:5"`
+			Pixels  []uint8 `d2b:"nibbles:true,count_prefix:u8"`
+		}
+		data := Sprite{Palette: []uint8{1, 2, 3, 0xf, 4}, Pixels: []uint8{0xa, 0xb}}
+		wire := []byte{0x12, 0x3f, 0x40, 2, 0xab}
+		Convey("Should decode palette indexes, packed to nibbles", func() {
+			var result Sprite
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should pack elements to nibbles", func() {
+			bytes, err := Encode(data, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error if element doesn't fit nibble", func() {
+			_, err := Encode(Sprite{Palette: []uint8{16}}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
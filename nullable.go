@@ -0,0 +1,67 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// nullableFields returns indexes of Valid and value fields of nullable struct type, which contains
+// bool Valid field and one value field, e.g. sql.NullInt64
+func nullableFields(t reflect.Type) (valid, value int, err error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.NumField() != 2 {
+		return 0, 0, errors.Errorf("nullable field should be struct with Valid and value fields, not %v", t)
+	}
+	f, ok := t.FieldByName("Valid")
+	if !ok || f.Type.Kind() != reflect.Bool {
+		return 0, 0, errors.Errorf("nullable field %v should have bool Valid field", t)
+	}
+	valid = f.Index[0]
+	return valid, 1 - valid, nil
+}
+
+// decodeNullable reads presence flag to Valid field and decodes value field only if it's set
+func (d *Decoder) decodeNullable(v reflect.Value, endian binary.ByteOrder) error {
+	validIndex, valueIndex, err := nullableFields(v.Type())
+	if err != nil {
+		return err
+	}
+	flag, err := d.next(1)
+	if err != nil {
+		return err
+	}
+	valid := flag[0] != 0
+	v.Field(validIndex).SetBool(valid)
+	if !valid {
+		v.Field(valueIndex).Set(reflect.Zero(v.Field(valueIndex).Type()))
+		return nil
+	}
+	tags, err := getStructTags(v.Type(), d.TagKey)
+	if err != nil {
+		return errors.Wrap(err, "can't parse struct tags")
+	}
+	return d.decodeStructField(v, v.Field(valueIndex), tags[valueIndex], endian)
+}
+
+// nullableToBytes writes presence flag and value field only if Valid field is set
+func (e *Encoder) nullableToBytes(v reflect.Value, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	validIndex, valueIndex, err := nullableFields(v.Type())
+	if err != nil {
+		return err
+	}
+	if !v.Field(validIndex).Bool() {
+		buffer.WriteByte(0)
+		return nil
+	}
+	buffer.WriteByte(1)
+	tags, err := e.getStructTags(v.Type())
+	if err != nil {
+		return errors.Wrap(err, "can't parse struct tags")
+	}
+	return e.structFieldValueToBytes(v, v.Field(valueIndex), tags[valueIndex], buffer, endian)
+}
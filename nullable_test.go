@@ -0,0 +1,54 @@
+package d2b
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNullable(t *testing.T) {
+	Convey("Test nullable fields", t, func() {
+		type NullName struct {
+			Valid bool
+			Value string `d2b:"length:3"`
+		}
+		type Struct struct {
+			ID    sql.NullInt64 `d2b:"null_flag:true"`
+			Name  NullName      `d2b:"null_flag:true"`
+			Count *NullName     `d2b:"null_flag:true"`
+			Tail  uint8
+		}
+		Convey("Should decode present and absent values", func() {
+			var result Struct
+			err := Decode([]byte{
+				1, 5, 0, 0, 0, 0, 0, 0, 0,
+				0,
+				1, 'a', 'b', 'c',
+				7,
+			}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{
+				ID:    sql.NullInt64{Int64: 5, Valid: true},
+				Count: &NullName{Valid: true, Value: "abc"},
+				Tail:  7,
+			})
+		})
+		Convey("Should encode present and absent values", func() {
+			bytes, err := Encode(Struct{
+				ID:   sql.NullInt64{Int64: 5},
+				Name: NullName{Valid: true, Value: "ab"},
+				Tail: 7,
+			}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 1, 'a', 'b', 0, 0, 7})
+		})
+		Convey("Should return error for structs without Valid field", func() {
+			type Bad struct {
+				A struct{ B, C uint8 } `d2b:"null_flag:true"`
+			}
+			So(Decode([]byte{1, 2, 3}, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,354 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// decodeNumberField decodes numeric struct field, applying its tag options
+func (d *Decoder) decodeNumberField(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	if tags.ASCII {
+		return d.decodeASCIINumber(v, tags)
+	}
+	if tags.Varint != "" {
+		return d.decodeVarint(v, tags)
+	}
+	if tags.Duration != 0 {
+		bytes, err := d.next(tags.width(8))
+		if err != nil {
+			return err
+		}
+		v.SetInt(readInt(bytes, endian) * int64(tags.Duration))
+		return nil
+	}
+	if tags.Mantissa != 0 {
+		value, err := d.decodeMantissaExp(tags, endian)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(value)
+		return nil
+	}
+	if tags.scaled() {
+		bytes, err := d.next(tags.width(int(v.Type().Size())))
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(readInt(bytes, endian))*tags.scale() + tags.OffsetVal)
+		return nil
+	}
+	if tags.Width != 0 && isInteger(v.Kind()) {
+		bytes, err := d.next(tags.Width)
+		if err != nil {
+			return err
+		}
+		if isSigned(v.Kind()) && d.ForceUnsigned {
+			setClampedInt(v, readUint(bytes, endian))
+		} else if isSigned(v.Kind()) {
+			v.SetInt(readInt(bytes, endian))
+		} else {
+			v.SetUint(readUint(bytes, endian))
+		}
+		if tags.Enum != nil {
+			return checkEnum(tags.Enum, v)
+		}
+		return nil
+	}
+	if !tags.WordSwap {
+		err := d.decodeValue(v, endian)
+		if err != nil || tags.Enum == nil {
+			return err
+		}
+		return checkEnum(tags.Enum, v)
+	}
+	bytes, err := d.next(int(v.Type().Size()))
+	if err != nil {
+		return err
+	}
+	swapped := make([]byte, len(bytes))
+	copy(swapped, bytes)
+	swapWords(swapped)
+	d.setNumber(v, swapped, endian)
+	if tags.Enum != nil {
+		return checkEnum(tags.Enum, v)
+	}
+	return nil
+}
+
+// numberFieldToBytes encodes numeric struct field, applying its tag options
+func (e *Encoder) numberFieldToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	v = e.normalizeNaN(v)
+	if ft.ASCII {
+		return asciiNumberToBytes(v, ft, buffer)
+	}
+	if ft.Varint != "" {
+		varintToBytes(v, ft, buffer)
+		return nil
+	}
+	if ft.Duration != 0 {
+		return e.durationToBytes(v, ft, buffer, endian)
+	}
+	if ft.Mantissa != 0 {
+		round, err := roundFunc(e.RoundMode)
+		if err != nil {
+			return err
+		}
+		b, err := mantissaExpBytes(v.Float(), ft, endian, round)
+		if err != nil {
+			return err
+		}
+		buffer.Write(b)
+		return nil
+	}
+	if ft.scaled() {
+		round, err := roundFunc(e.RoundMode)
+		if err != nil {
+			return err
+		}
+		raw := round((v.Float() - ft.OffsetVal) / ft.scale())
+		if math.IsNaN(raw) || math.IsInf(raw, 0) {
+			return errors.Errorf("value %v can't be stored as scaled integer", v.Float())
+		}
+		// float64(math.MaxInt64) is 2^63, which overflows int64, so values are clamped as integers
+		var value int64
+		if raw >= 1<<63 {
+			value = math.MaxInt64
+		} else if raw < -1<<63 {
+			value = math.MinInt64
+		} else {
+			value = int64(raw)
+		}
+		return e.putInt(value, ft.width(int(v.Type().Size())), ft, buffer, endian)
+	}
+	if ft.Enum != nil {
+		err := checkEnum(ft.Enum, v)
+		if err != nil {
+			return err
+		}
+	}
+	if ft.Width != 0 && isSigned(v.Kind()) {
+		return e.putInt(v.Int(), ft.Width, ft, buffer, endian)
+	}
+	if ft.Width != 0 && isInteger(v.Kind()) {
+		return e.putUint(v.Uint(), ft.Width, ft, buffer, endian)
+	}
+	if !ft.WordSwap {
+		return e.valueToBytes(v, buffer, endian)
+	}
+	b := numberBytes(v, endian)
+	swapWords(b)
+	buffer.Write(b)
+	return nil
+}
+
+// Rounding modes of Encoder
+const (
+	RoundNearest  = "nearest"
+	RoundTruncate = "truncate"
+	RoundFloor    = "floor"
+	RoundCeil     = "ceil"
+)
+
+// roundFunc returns function, which rounds floats with given mode. Empty mode is RoundNearest
+func roundFunc(mode string) (func(float64) float64, error) {
+	switch mode {
+	case "", RoundNearest:
+		return math.Round, nil
+	case RoundTruncate:
+		return math.Trunc, nil
+	case RoundFloor:
+		return math.Floor, nil
+	case RoundCeil:
+		return math.Ceil, nil
+	}
+	return nil, errors.Errorf("bad round mode %q", mode)
+}
+
+// canonicalNaN is a bit pattern of NaN, written by encoder with NormalizeNaN option. It's converted
+// to 0x7fc00000 for float32 values
+const canonicalNaN = 0x7ff8000000000000
+
+// normalizeNaN returns canonical NaN value of v's type if v is NaN and NormalizeNaN option is set,
+// or v itself otherwise
+func (e *Encoder) normalizeNaN(v reflect.Value) reflect.Value {
+	if !e.NormalizeNaN || v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 || !math.IsNaN(v.Float()) {
+		return v
+	}
+	result := reflect.New(v.Type()).Elem()
+	result.SetFloat(math.Float64frombits(canonicalNaN))
+	return result
+}
+
+// putInt writes signed value as integer of given width. Value, which doesn't fit the width, is
+// clamped if saturate option is set, and wrapped around otherwise
+func (e *Encoder) putInt(value int64, width int, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if width < 8 {
+		max := int64(1)<<uint(8*width-1) - 1
+		min := -max - 1
+		if value > max || value < min {
+			if e.ErrorOnOverflow {
+				return errors.Errorf("value %d overflows %d-byte integer", value, width)
+			}
+			if ft.Saturate && value > max {
+				value = max
+			} else if ft.Saturate {
+				value = min
+			}
+		}
+	}
+	b := make([]byte, width)
+	putUint(b, endian, uint64(value))
+	buffer.Write(b)
+	return nil
+}
+
+// putUint writes unsigned value as integer of given width. Value, which doesn't fit the width, is
+// clamped if saturate option is set, and wrapped around otherwise
+func (e *Encoder) putUint(value uint64, width int, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if width < 8 {
+		max := uint64(1)<<uint(8*width) - 1
+		if value > max {
+			if e.ErrorOnOverflow {
+				return errors.Errorf("value %d overflows %d-byte integer", value, width)
+			}
+			if ft.Saturate {
+				value = max
+			}
+		}
+	}
+	b := make([]byte, width)
+	putUint(b, endian, value)
+	buffer.Write(b)
+	return nil
+}
+
+// isInteger returns true if kind is one of fixed size integer kinds
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// isSigned returns true if kind is one of fixed size signed integer kinds
+func isSigned(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// numberBytes returns bytes representation of numeric value v
+func numberBytes(v reflect.Value, endian binary.ByteOrder) []byte {
+	b := make([]byte, v.Type().Size())
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		putUint(b, endian, uint64(v.Int()))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		putUint(b, endian, v.Uint())
+	case reflect.Float32:
+		putUint(b, endian, uint64(math.Float32bits(float32(v.Float()))))
+	case reflect.Float64:
+		putUint(b, endian, math.Float64bits(v.Float()))
+	}
+	return b
+}
+
+// swapWords reverses order of 16-bit words in b
+func swapWords(b []byte) {
+	for i, j := 0, len(b)-2; i < j; i, j = i+2, j-2 {
+		b[i], b[i+1], b[j], b[j+1] = b[j], b[j+1], b[i], b[i+1]
+	}
+}
+
+// checkWordSwap checks, that field with wordswap option is 32 or 64-bit number
+func checkWordSwap(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return nil
+	}
+	return errors.Errorf("word swapped field should be 32 or 64-bit number, not %v", t)
+}
+
+// checkFloat checks, that field with given option is float
+func checkFloat(t reflect.Type, option string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return nil
+	}
+	return errors.Errorf("field with %s option should be float, not %v", option, t)
+}
+
+// parseMantissaExp parses widths of mantissa and exponent, e.g. m16/e8
+func parseMantissaExp(value string) (mantissa, exponent int, err error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "m") || !strings.HasPrefix(parts[1], "e") {
+		return 0, 0, errors.Errorf("bad mantissa and exponent widths %q", value)
+	}
+	mantissa, err = parseWidth("u" + parts[0][1:])
+	if err != nil {
+		return 0, 0, err
+	}
+	exponent, err = parseWidth("u" + parts[1][1:])
+	return mantissa, exponent, err
+}
+
+// decodeMantissaExp reads signed mantissa and exponent and returns mantissa * 2^exponent
+func (d *Decoder) decodeMantissaExp(tags *structFieldTag, endian binary.ByteOrder) (float64, error) {
+	bytes, err := d.next(tags.Mantissa + tags.Exponent)
+	if err != nil {
+		return 0, err
+	}
+	mantissa := readInt(bytes[:tags.Mantissa], endian)
+	exponent := readInt(bytes[tags.Mantissa:], endian)
+	return math.Ldexp(float64(mantissa), int(exponent)), nil
+}
+
+// mantissaExpBytes returns signed mantissa and exponent of value, so value = mantissa * 2^exponent.
+// Mantissa is rounded with round function
+func mantissaExpBytes(value float64, ft *structFieldTag, endian binary.ByteOrder, round func(float64) float64) ([]byte, error) {
+	mantissaBits := uint(8*ft.Mantissa - 1)
+	minExponent := -int64(1) << uint(8*ft.Exponent-1)
+	maxExponent := -minExponent - 1
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, errors.Errorf("value %v can't be stored as mantissa and exponent", value)
+	}
+	var mantissa, exponent int64
+	if value != 0 {
+		frac, exp := math.Frexp(value)
+		mantissa = int64(round(math.Ldexp(frac, int(mantissaBits))))
+		exponent = int64(exp) - int64(mantissaBits)
+		if mantissa == int64(1)<<mantissaBits || mantissa == -int64(1)<<mantissaBits {
+			mantissa /= 2
+			exponent++
+		}
+		for exponent < minExponent && mantissa != 0 {
+			mantissa /= 2
+			exponent++
+		}
+		if mantissa == 0 {
+			exponent = 0
+		}
+		if exponent > maxExponent {
+			return nil, errors.Errorf("value %v doesn't fit %d-byte mantissa and %d-byte exponent", value, ft.Mantissa, ft.Exponent)
+		}
+	}
+	b := make([]byte, ft.Mantissa+ft.Exponent)
+	putUint(b[:ft.Mantissa], endian, uint64(mantissa))
+	putUint(b[ft.Mantissa:], endian, uint64(exponent))
+	return b, nil
+}
@@ -0,0 +1,334 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWordSwap(t *testing.T) {
+	Convey("Test word swapped fields", t, func() {
+		type Registers struct {
+			A uint32  `d2b:"wordswap:true"`
+			B float32 `d2b:"wordswap:true"`
+			C int64   `d2b:"wordswap:true"`
+			D uint32
+		}
+		data := Registers{A: 0x12345678, B: 123.456, C: 0x0102030405060708, D: 0x12345678}
+		wire := []byte{
+			0x56, 0x78, 0x12, 0x34,
+			0xe9, 0x79, 0x42, 0xf6,
+			0x07, 0x08, 0x05, 0x06, 0x03, 0x04, 0x01, 0x02,
+			0x12, 0x34, 0x56, 0x78,
+		}
+		Convey("Should decode Modbus-style word swapped registers", func() {
+			var result Registers
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should encode Modbus-style word swapped registers", func() {
+			bytes, err := Encode(data, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error for wordswap on 16-bit field", func() {
+			type Bad struct {
+				A uint16 `d2b:"wordswap:true"`
+			}
+			So(Decode(wire, binary.BigEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestScale(t *testing.T) {
+	Convey("Test scaled fields", t, func() {
+		type Sensor struct {
+			Temperature float64 `d2b:"scale:0.1,offset_val:-40,width:2"`
+			Humidity    float32 `d2b:"scale:0.5,width:1"`
+		}
+		Convey("Should decode scaled values", func() {
+			var result Sensor
+			err := Decode([]byte{0x01, 0x9b, 0x5b}, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Temperature, ShouldAlmostEqual, 1.1, 1e-9)
+			So(result.Humidity, ShouldAlmostEqual, 45.5, 1e-6)
+		})
+		Convey("Should round trip scaled values", func() {
+			data := Sensor{Temperature: -12.34, Humidity: 60}
+			bytes, err := Encode(data, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x01, 0x15, 0x78})
+			var result Sensor
+			err = Decode(bytes, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Temperature, ShouldAlmostEqual, data.Temperature, 0.05)
+			So(result.Humidity, ShouldAlmostEqual, data.Humidity, 0.25)
+		})
+		Convey("Should return error for scale on integer field", func() {
+			type Bad struct {
+				A uint16 `d2b:"scale:0.1"`
+			}
+			So(Decode([]byte{1, 2}, binary.BigEndian, &Bad{}), ShouldNotBeNil)
+		})
+		Convey("Should clamp out of range values with saturate", func() {
+			type Saturated struct {
+				A float64 `d2b:"scale:1,width:2,saturate:true"`
+				B float64 `d2b:"scale:1"`
+			}
+			bytes, err := Encode(Saturated{A: 1e30, B: 1e30}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x7f, 0xff, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+			bytes, err = Encode(Saturated{A: -1e30, B: -1e30}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x80, 0, 0x80, 0, 0, 0, 0, 0, 0, 0})
+		})
+		Convey("Should return error for NaN and infinite values", func() {
+			_, err := Encode(Sensor{Temperature: math.NaN()}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Sensor{Temperature: math.Inf(1)}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Sensor{Humidity: float32(math.Inf(-1))}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSaturate(t *testing.T) {
+	Convey("Test integer fields of declared width", t, func() {
+		type Struct struct {
+			A int32  `d2b:"width:1,saturate:true"`
+			B uint64 `d2b:"width:2,saturate:true"`
+			C int16  `d2b:"width:1"`
+		}
+		Convey("Should clamp values above max", func() {
+			bytes, err := Encode(Struct{A: 1000, B: 70000, C: 0x1ff}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x7f, 0xff, 0xff, 0xff})
+		})
+		Convey("Should clamp values below min", func() {
+			bytes, err := Encode(Struct{A: -1000, B: 1, C: -1}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x80, 0x01, 0x00, 0xff})
+		})
+		Convey("Should return error on overflow with ErrorOnOverflow", func() {
+			encoder := NewEncoder(binary.LittleEndian)
+			encoder.ErrorOnOverflow = true
+			_, err := encoder.Encode(Struct{A: 1000})
+			So(err, ShouldNotBeNil)
+			_, err = encoder.Encode(Struct{C: 128})
+			So(err, ShouldNotBeNil)
+			_, err = encoder.Encode(Struct{A: -128, B: 65535, C: 127})
+			So(err, ShouldBeNil)
+		})
+		Convey("Should decode values of declared width", func() {
+			var result Struct
+			err := Decode([]byte{0x80, 0xff, 0xff, 0x7f}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{A: -128, B: 65535, C: 127})
+		})
+	})
+}
+
+func TestWidth(t *testing.T) {
+	Convey("Test integers, narrower on the wire than Go fields", t, func() {
+		type Struct struct {
+			A int32  `d2b:"width:1"`
+			B int64  `d2b:"width:1"`
+			C int64  `d2b:"width:2"`
+			D uint32 `d2b:"width:1"`
+		}
+		Convey("Should sign-extend signed values", func() {
+			var result Struct
+			err := Decode([]byte{0xfe, 0x80, 0xff, 0x7f, 0xff}, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{A: -2, B: -128, C: -129, D: 255})
+		})
+		Convey("Should round trip values of declared width", func() {
+			data := Struct{A: -2, B: 127, C: -32768, D: 200}
+			bytes, err := Encode(data, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0xfe, 0x7f, 0x80, 0x00, 0xc8})
+			var result Struct
+			err = Decode(bytes, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+	})
+}
+
+func TestMantissaExp(t *testing.T) {
+	Convey("Test floats, stored as mantissa and exponent", t, func() {
+		type Reading struct {
+			Value float64 `d2b:"mantissa_exp:m16/e8"`
+			Small float32 `d2b:"mantissa_exp:m8/e8"`
+		}
+		Convey("Should decode mantissa * 2^exponent", func() {
+			var result Reading
+			err := Decode([]byte{0x00, 0x03, 0x02, 0xfd, 0xfe}, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Reading{Value: 12, Small: -0.75})
+		})
+		Convey("Should round trip values within precision", func() {
+			for _, value := range []float64{0, 1, -1, 3.14159, -1234.5678, 1e-5, 6.02e23} {
+				bytes, err := Encode(Reading{Value: value, Small: float32(value)}, binary.BigEndian)
+				So(err, ShouldBeNil)
+				var result Reading
+				err = Decode(bytes, binary.BigEndian, &result)
+				So(err, ShouldBeNil)
+				So(result.Value, ShouldAlmostEqual, value, math.Abs(value)/(1<<14))
+				So(result.Small, ShouldAlmostEqual, value, math.Abs(value)/(1<<6))
+			}
+		})
+		Convey("Should return error if exponent doesn't fit", func() {
+			_, err := Encode(Reading{Value: 1e300}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for NaN and infinite values", func() {
+			_, err := Encode(Reading{Value: math.NaN()}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Reading{Value: math.Inf(1)}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+			_, err = Encode(Reading{Small: float32(math.Inf(-1))}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for bad widths", func() {
+			type Bad struct {
+				A float64 `d2b:"mantissa_exp:16/8"`
+			}
+			So(Decode(make([]byte, 3), binary.BigEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestASCIINumbers(t *testing.T) {
+	Convey("Test numbers, stored as ASCII text", t, func() {
+		type Record struct {
+			Count  int32   `d2b:"ascii:true,length:6,pad:0x30"`
+			Amount float64 `d2b:"ascii:true,length:8,justify:left"`
+			Code   uint8   `d2b:"ascii:true,length:4"`
+		}
+		Convey("Should round trip zero padded integers", func() {
+			for _, count := range []int32{0, 42, 999999, -4200} {
+				data := Record{Count: count}
+				bytes, err := Encode(data, binary.BigEndian)
+				So(err, ShouldBeNil)
+				var result Record
+				err = Decode(bytes, binary.BigEndian, &result)
+				So(err, ShouldBeNil)
+				So(result, ShouldResemble, data)
+			}
+		})
+		Convey("Should justify and pad text", func() {
+			bytes, err := Encode(Record{Count: 420, Amount: 12.5, Code: 7}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(string(bytes), ShouldEqual, "00042012.5       7")
+			bytes, err = Encode(Record{Count: -42}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(string(bytes), ShouldEqual, "-000420          0")
+		})
+		Convey("Should return error if number doesn't fit length", func() {
+			_, err := Encode(Record{Count: 1000000}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for non-digit text", func() {
+			So(Decode([]byte("00a042       0   7"), binary.BigEndian, &Record{}), ShouldNotBeNil)
+		})
+		Convey("Should decode and encode ASCII decimal length of payload", func() {
+			type Message struct {
+				Body []byte `d2b:"length_ascii:4"`
+				Text string `d2b:"length_ascii:4"`
+			}
+			wire := []byte("0005hello0000")
+			var result Message
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Message{Body: []byte("hello")})
+			bytes, err := Encode(result, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+
+			So(Decode([]byte("00x5hello0000"), binary.BigEndian, &result), ShouldNotBeNil)
+			_, err = Encode(Message{Text: string(make([]byte, 10000))}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for ascii field without length", func() {
+			type Bad struct {
+				A int32 `d2b:"ascii:true"`
+			}
+			So(Decode([]byte("1"), binary.BigEndian, &Bad{}), ShouldNotBeNil)
+		})
+		Convey("Should return error for left justified field, padded with digit", func() {
+			type Bad struct {
+				A int32 `d2b:"ascii:true,length:4,justify:left,pad:0x30"`
+			}
+			_, err := Encode(Bad{A: 42}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestNormalizeNaN(t *testing.T) {
+	Convey("Test NaN normalization", t, func() {
+		type Reading struct {
+			A float64
+			B float32 `d2b:"wordswap:true"`
+			C [2]float32
+		}
+		a := Reading{A: math.Float64frombits(0x7ff0000000000001), B: float32(math.NaN()), C: [2]float32{1, math.Float32frombits(0xffc00001)}}
+		b := Reading{A: math.Float64frombits(0xfff8000000000abc), B: math.Float32frombits(0x7f800001), C: [2]float32{1, float32(math.NaN())}}
+		Convey("Should encode different NaNs identically with NormalizeNaN", func() {
+			encoder := NewEncoder(binary.BigEndian)
+			encoder.NormalizeNaN = true
+			aBytes, err := encoder.Encode(a)
+			So(err, ShouldBeNil)
+			bBytes, err := encoder.Encode(b)
+			So(err, ShouldBeNil)
+			So(aBytes, ShouldResemble, bBytes)
+			So(aBytes[:8], ShouldResemble, []byte{0x7f, 0xf8, 0, 0, 0, 0, 0, 0})
+			So(aBytes[16:], ShouldResemble, []byte{0x7f, 0xc0, 0, 0})
+		})
+		Convey("Should keep NaN bits by default", func() {
+			aBytes, err := Encode(a, binary.BigEndian)
+			So(err, ShouldBeNil)
+			bBytes, err := Encode(b, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(aBytes, ShouldNotResemble, bBytes)
+		})
+	})
+}
+
+func TestRoundMode(t *testing.T) {
+	Convey("Test rounding modes of encoder", t, func() {
+		type Reading struct {
+			Positive float64 `d2b:"scale:0.5,width:1"`
+			Negative float64 `d2b:"scale:0.5,width:1"`
+			Value    float64 `d2b:"mantissa_exp:m8/e8"`
+		}
+		data := Reading{Positive: 1.25, Negative: -1.25, Value: 1.00390625}
+		for _, c := range []struct {
+			mode     string
+			expected []byte
+		}{
+			{"", []byte{3, 0xfd, 64, 0xfa}},
+			{RoundNearest, []byte{3, 0xfd, 64, 0xfa}},
+			{RoundTruncate, []byte{2, 0xfe, 64, 0xfa}},
+			{RoundFloor, []byte{2, 0xfd, 64, 0xfa}},
+			{RoundCeil, []byte{3, 0xfe, 65, 0xfa}},
+		} {
+			encoder := NewEncoder(binary.BigEndian)
+			encoder.RoundMode = c.mode
+			bytes, err := encoder.Encode(data)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, c.expected)
+		}
+		Convey("Should return error for bad round mode", func() {
+			encoder := NewEncoder(binary.BigEndian)
+			encoder.RoundMode = "up"
+			_, err := encoder.Encode(data)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOmitEmpty(t *testing.T) {
+	Convey("Test omit_empty trailing fields", t, func() {
+		type Message struct {
+			ID      uint16
+			Flags   uint8
+			Extra   uint16   `d2b:"omit_empty:true"`
+			Options [2]uint8 `d2b:"omit_empty:true"`
+		}
+		Convey("Should omit empty trailers on encode", func() {
+			bytes, err := Encode(Message{ID: 1, Flags: 2}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 1, 2})
+
+			bytes, err = Encode(Message{ID: 1, Flags: 2, Extra: 3}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 1, 2, 0, 3})
+		})
+		Convey("Should write zero trailers, which precede non-empty ones", func() {
+			bytes, err := Encode(Message{ID: 1, Options: [2]uint8{4, 5}}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 1, 0, 0, 0, 4, 5})
+		})
+		Convey("Should decode omitted trailers as zero with AllowTruncation", func() {
+			decoder := NewDecoder([]byte{0, 1, 2, 0, 3}, binary.BigEndian)
+			decoder.AllowTruncation = true
+			result := Message{Options: [2]uint8{9, 9}}
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Message{ID: 1, Flags: 2, Extra: 3})
+
+			So(Decode([]byte{0, 1, 2}, binary.BigEndian, &Message{}), ShouldNotBeNil)
+		})
+		Convey("Should return error if omit_empty field is followed by regular one", func() {
+			type Bad struct {
+				A uint8 `d2b:"omit_empty:true"`
+				B uint8
+			}
+			_, err := Encode(Bad{}, binary.BigEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
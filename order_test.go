@@ -0,0 +1,61 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrder(t *testing.T) {
+	Convey("Test fields with explicit order", t, func() {
+		type Reversed struct {
+			Name    string `d2b:"length_from:Len,order:3"`
+			Len     uint8  `d2b:"order:2"`
+			Skipped int    `d2b:"-"`
+			B       uint16 `d2b:"order:1"`
+			A       uint8  `d2b:"order:0"`
+		}
+		data := Reversed{Name: "hi", Len: 2, B: 0x0102, A: 7}
+		wire := []byte{7, 2, 1, 2, 'h', 'i'}
+		Convey("Should encode fields in declared order", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should decode fields in declared order", func() {
+			var result Reversed
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should return plan in declared order", func() {
+			plans, err := Plan(Reversed{})
+			So(err, ShouldBeNil)
+			So(plans[0].Path, ShouldEqual, "A")
+			So(plans[2].Offset, ShouldEqual, 3)
+		})
+		Convey("Should return error if orders don't form complete sequence", func() {
+			type Missing struct {
+				A uint8 `d2b:"order:0"`
+				B uint8
+			}
+			type Duplicate struct {
+				A uint8 `d2b:"order:0"`
+				B uint8 `d2b:"order:0"`
+			}
+			type Gap struct {
+				A uint8 `d2b:"order:0"`
+				B uint8 `d2b:"order:2"`
+			}
+			type LengthAfter struct {
+				Name string `d2b:"length_from:Len,order:0"`
+				Len  uint8  `d2b:"order:1"`
+			}
+			So(Decode(wire, binary.LittleEndian, &Missing{}), ShouldNotBeNil)
+			So(Decode(wire, binary.LittleEndian, &Duplicate{}), ShouldNotBeNil)
+			So(Decode(wire, binary.LittleEndian, &Gap{}), ShouldNotBeNil)
+			So(Decode(wire, binary.LittleEndian, &LengthAfter{}), ShouldNotBeNil)
+		})
+	})
+}
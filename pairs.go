@@ -0,0 +1,117 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// resolvePairs checks, that pairs option of keys field refers to slice field, and marks it as skipped,
+// because its elements are encoded and decoded together with keys
+func resolvePairs(structType reflect.Type, index int, tags []*structFieldTag) error {
+	tag := tags[index]
+	for i := 0; i < structType.NumField(); i++ {
+		ft := structType.Field(i)
+		if ft.Name != tag.Pairs || i == index {
+			continue
+		}
+		if ft.Type.Kind() != reflect.Slice {
+			return errors.Errorf("pairs field %s should be slice", tag.Pairs)
+		}
+		tag.pairsIndex = i
+		tag.pairsType = ft.Type
+		tags[i].Skip = true
+		return nil
+	}
+	return errors.Errorf("pairs field %s is not found", tag.Pairs)
+}
+
+// decodePairs decodes interleaved elements of keys slice and paired values slice
+func (d *Decoder) decodePairs(parent, keys reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	values := parent.Field(tags.pairsIndex)
+	length, err := tags.length(parent)
+	if err != nil {
+		return err
+	}
+	err = tags.checkCount(length)
+	if err != nil {
+		return err
+	}
+	// pair of unknown size takes at least one byte
+	pairLen, err := (&Encoder{TagKey: d.TagKey, Version: d.Version}).getPairBytesLength(keys.Type(), values.Type())
+	if err != nil {
+		pairLen = 1
+	}
+	err = d.checkAllocation(uint64(length), pairLen)
+	if err != nil {
+		return err
+	}
+	newKeys := d.makeSlice(keys.Type(), length)
+	newValues := d.makeSlice(values.Type(), length)
+	for i := 0; i < length; i++ {
+		err = d.decodeValue(newKeys.Index(i), endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't decode key %d", i)
+		}
+		err = d.decodeValue(newValues.Index(i), endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't decode value %d", i)
+		}
+	}
+	keys.Set(newKeys)
+	values.Set(newValues)
+	return nil
+}
+
+// pairsToBytes encodes elements of keys slice and paired values slice one after another in their order
+func (e *Encoder) pairsToBytes(parent, keys reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	values := parent.Field(ft.pairsIndex)
+	if keys.Len() != values.Len() {
+		return errors.Errorf("keys count %d doesn't match values count %d", keys.Len(), values.Len())
+	}
+	length, err := ft.length(parent)
+	if err != nil {
+		return err
+	}
+	err = ft.checkCount(length)
+	if err != nil {
+		return err
+	}
+	handleLength := keys.Len()
+	if length < handleLength {
+		handleLength = length
+	}
+	for i := 0; i < handleLength; i++ {
+		err = e.valueToBytes(keys.Index(i), buffer, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't convert key %d to bytes", i)
+		}
+		err = e.valueToBytes(values.Index(i), buffer, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't convert value %d to bytes", i)
+		}
+	}
+	if handleLength < length {
+		pairLen, err := e.getPairBytesLength(keys.Type(), values.Type())
+		if err != nil {
+			return err
+		}
+		buffer.Write(make([]byte, pairLen*(length-handleLength)))
+	}
+	return nil
+}
+
+// getPairBytesLength returns length of key and value elements of keys and values slice types
+func (e *Encoder) getPairBytesLength(keysType, valuesType reflect.Type) (int, error) {
+	keyLen, err := e.getTypeBytesLength(keysType.Elem())
+	if err != nil {
+		return 0, errors.Wrap(err, "can't detect key length")
+	}
+	valueLen, err := e.getTypeBytesLength(valuesType.Elem())
+	if err != nil {
+		return 0, errors.Wrap(err, "can't detect value length")
+	}
+	return keyLen + valueLen, nil
+}
@@ -0,0 +1,66 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPairs(t *testing.T) {
+	Convey("Test paired keys and values slices", t, func() {
+		type Struct struct {
+			Count  uint8
+			Keys   []uint8 `d2b:"length_from:Count,pairs:Values"`
+			Values []int16
+		}
+		Convey("Should encode pairs in the order of slices", func() {
+			data := Struct{Count: 3, Keys: []uint8{9, 1, 5}, Values: []int16{-1, 2, 3}}
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{3, 9, 0xff, 0xff, 1, 2, 0, 5, 3, 0})
+		})
+		Convey("Should decode pairs to both slices", func() {
+			var result Struct
+			err := Decode([]byte{3, 9, 0xff, 0xff, 1, 2, 0, 5, 3, 0}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{Count: 3, Keys: []uint8{9, 1, 5}, Values: []int16{-1, 2, 3}})
+		})
+		Convey("Should return error if pairs count exceeds data length", func() {
+			type Wide struct {
+				Count  uint32
+				Keys   []uint8 `d2b:"length_from:Count,pairs:Values"`
+				Values []int16
+			}
+			var result Wide
+			err := Decode([]byte{0xff, 0xff, 0xff, 0x7f, 1, 2}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should pad missing pairs with zeros", func() {
+			type Fixed struct {
+				Keys   []uint8 `d2b:"length:2,pairs:Values"`
+				Values []uint8
+			}
+			bytes, err := Encode(Fixed{Keys: []uint8{1}, Values: []uint8{2}}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 2, 0, 0})
+
+			var data *Fixed
+			bytes, err = Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 0, 0, 0})
+		})
+		Convey("Should return error if keys and values counts differ", func() {
+			_, err := Encode(Struct{Count: 2, Keys: []uint8{1, 2}, Values: []int16{1}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if pairs field is not slice", func() {
+			type Bad struct {
+				Keys   []uint8 `d2b:"length:2,pairs:Values"`
+				Values uint8
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,167 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// FieldPlan describes encoding/decoding of one struct field
+type FieldPlan struct {
+	// Path is dot separated path to the field, e.g. "Header.Flags"
+	Path string
+	// Kind is a kind of field value after pointers dereferencing
+	Kind reflect.Kind
+	// Offset is a field offset from the beginning of data or -1 if it depends on previous fields values
+	Offset int
+	// Size is a count of field bytes or -1 if it depends on field value
+	Size int
+	// Endian is a byte order, declared for the field or its parent. Nil means byte order of data or the one,
+	// selected by EndianFrom field
+	Endian binary.ByteOrder
+	// EndianFrom is a path of field, which selects byte order with endian_from option
+	EndianFrom string
+	// Length is a slice/string length, declared with length option
+	Length int
+	// LengthFrom is a name of field with slice/string length, declared with length_from option
+	LengthFrom string
+}
+
+// Plan returns sequence of fields, which are encoded/decoded for data struct type. Nested structs are
+// flattened, so plan contains only their leaf fields
+func Plan(data interface{}) ([]FieldPlan, error) {
+	return NewEncoder(nil).Plan(data)
+}
+
+// Plan returns sequence of fields like Plan function, reading struct tags with encoder's TagKey
+func (e *Encoder) Plan(data interface{}) ([]FieldPlan, error) {
+	t := reflect.TypeOf(data)
+	if t == nil {
+		return nil, errors.New("can't plan nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("data should be struct, not %v", t)
+	}
+	p := &planner{encoder: e}
+	_, err := p.planStruct(t, "", 0, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return p.plans, nil
+}
+
+// Size returns count of bytes, which data type takes when encoded. It returns error if the size
+// depends on data value, e.g. of slices with length_from option
+func Size(data interface{}) (int, error) {
+	return NewEncoder(nil).Size(data)
+}
+
+// Size returns count of bytes like Size function, reading struct tags with encoder's TagKey
+func (e *Encoder) Size(data interface{}) (int, error) {
+	t := reflect.TypeOf(data)
+	if t == nil {
+		return 0, errors.New("can't get size of nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	p := &planner{encoder: e}
+	if t.Kind() != reflect.Struct {
+		return p.encoder.getTypeBytesLength(t)
+	}
+	size, err := p.planStruct(t, "", 0, nil, "")
+	if err != nil {
+		return 0, err
+	}
+	if size == -1 {
+		return 0, errors.Errorf("size of %v depends on its value", t)
+	}
+	return size, nil
+}
+
+type planner struct {
+	encoder *Encoder
+	plans   []FieldPlan
+}
+
+// planStruct adds plans of struct fields, which starts at offset, and returns struct size. Byte order of
+// fields is endian or, if endianFrom is set, the one, selected by its field
+func (p *planner) planStruct(t reflect.Type, prefix string, offset int, endian binary.ByteOrder, endianFrom string) (int, error) {
+	info, err := getStructInfo(t, p.encoder.TagKey)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing %v struct tags error", t.Name())
+	}
+	tags := info.tags
+	// offsets are relative to the struct start
+	var unionStart, unionEnd int
+	for _, i := range info.order {
+		ft := t.Field(i)
+		tag := tags[i]
+		if tag.EndianFrom != "" {
+			endian, endianFrom = nil, prefix+tag.EndianFrom
+		}
+		if tag.Skip || !tag.present(p.encoder.Version) {
+			continue
+		}
+		if tag.Range != "" {
+			unionStart = tag.rangeFrom
+		} else if !tag.Union {
+			unionStart = unionEnd
+		}
+		fieldEndian, fieldEndianFrom := endian, endianFrom
+		if tag.Endian != nil {
+			fieldEndian, fieldEndianFrom = tag.Endian, ""
+		}
+		fieldType := ft.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		var size int
+		if fieldType.Kind() == reflect.Struct && !tag.NullFlag && !tag.Flags && tag.Compress == "" && tag.Decimal == 0 && tag.Transform == "" {
+			size, err = p.planStruct(fieldType, prefix+ft.Name+".", addOffset(offset, unionStart), fieldEndian, fieldEndianFrom)
+		} else {
+			size, err = p.fieldSize(fieldType, tag)
+			p.plans = append(p.plans, FieldPlan{
+				Path:       prefix + ft.Name,
+				Kind:       fieldType.Kind(),
+				Offset:     addOffset(offset, unionStart),
+				Size:       size,
+				Endian:     fieldEndian,
+				EndianFrom: fieldEndianFrom,
+				Length:     tag.Length,
+				LengthFrom: tag.LengthFrom,
+			})
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "%v.%v field", t.Name(), ft.Name)
+		}
+		end := addOffset(unionStart, size)
+		if end == -1 || unionEnd != -1 && end > unionEnd {
+			unionEnd = end
+		}
+	}
+	if fixedSize := structFixedSize(tags); fixedSize != 0 {
+		return fixedSize, nil
+	}
+	return unionEnd, nil
+}
+
+// fieldSize returns size of field or -1 if it depends on field value
+func (p *planner) fieldSize(t reflect.Type, tag *structFieldTag) (int, error) {
+	if tag.LengthFrom != "" || tag.LengthMethod != "" || tag.Rest || tag.CountExpr != "" || tag.CountPrefix != 0 || tag.BytePrefix != 0 || tag.PString != 0 || tag.LengthASCII != 0 || tag.RLE != 0 || tag.Varint != "" || tag.Terminator != "" || tag.WidthFrom != "" || tag.Compress != "" || tag.Fn != "" || tag.Transform != "" || tag.NullFlag || t.Kind() == reflect.Interface {
+		return -1, nil
+	}
+	return p.encoder.getStructFieldTypeBytesLength(t, tag)
+}
+
+// addOffset returns offset + size or -1 if any of them is unknown
+func addOffset(offset, size int) int {
+	if offset == -1 || size == -1 {
+		return -1
+	}
+	return offset + size
+}
@@ -0,0 +1,79 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPlan(t *testing.T) {
+	Convey("Test Plan", t, func() {
+		Convey("Should return plan of representative struct", func() {
+			type Header struct {
+				Version uint8
+				Flags   uint16 `d2b:"endian:big"`
+			}
+			type Packet struct {
+				Header  *Header
+				Name    string `d2b:"length:4"`
+				Skipped int    `d2b:"-"`
+				Values  [3]int16
+				Raw     uint32
+				AsFloat float32 `d2b:"union:true"`
+				Len     uint8
+				Payload []byte `d2b:"length_from:Len"`
+				Tail    uint8
+			}
+			plans, err := Plan(&Packet{})
+			So(err, ShouldBeNil)
+			So(plans, ShouldResemble, []FieldPlan{
+				{Path: "Header.Version", Kind: reflect.Uint8, Offset: 0, Size: 1},
+				{Path: "Header.Flags", Kind: reflect.Uint16, Offset: 1, Size: 2, Endian: binary.BigEndian},
+				{Path: "Name", Kind: reflect.String, Offset: 3, Size: 4, Length: 4},
+				{Path: "Values", Kind: reflect.Array, Offset: 7, Size: 6},
+				{Path: "Raw", Kind: reflect.Uint32, Offset: 13, Size: 4},
+				{Path: "AsFloat", Kind: reflect.Float32, Offset: 13, Size: 4},
+				{Path: "Len", Kind: reflect.Uint8, Offset: 17, Size: 1},
+				{Path: "Payload", Kind: reflect.Slice, Offset: 18, Size: -1, LengthFrom: "Len"},
+				{Path: "Tail", Kind: reflect.Uint8, Offset: -1, Size: 1},
+			})
+		})
+		Convey("Should return error for non-struct types", func() {
+			_, err := Plan(5)
+			So(err, ShouldNotBeNil)
+			_, err = Plan(nil)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for unsupported fields", func() {
+			type Bad struct {
+				A int
+			}
+			_, err := Plan(Bad{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSize(t *testing.T) {
+	Convey("Test Size", t, func() {
+		type Fixed struct {
+			A uint16
+			B [3]int32
+			C string `d2b:"length:5"`
+		}
+		size, err := Size(&Fixed{})
+		So(err, ShouldBeNil)
+		So(size, ShouldEqual, 19)
+		size, err = Size(uint64(0))
+		So(err, ShouldBeNil)
+		So(size, ShouldEqual, 8)
+		type Variable struct {
+			Len  uint8
+			Data []byte `d2b:"length_from:Len"`
+		}
+		_, err = Size(Variable{})
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,118 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// DecodeProtoLite parses protobuf wire format and sets values of fields to targets, keyed by field numbers.
+// Targets should be pointers to integers or bools for varint fields, to uint64/int64/float64 for fixed64 fields,
+// to uint32/int32/float32 for fixed32 fields and to []byte or string for length-delimited fields.
+// Fields without targets are skipped. If field is repeated, the last value is set
+func DecodeProtoLite(bytes []byte, fields map[int]interface{}) error {
+	d := NewDecoder(bytes, binary.LittleEndian)
+	for d.Remaining() > 0 {
+		key, err := d.nextUvarint()
+		if err != nil {
+			return errors.Wrap(err, "can't read field key")
+		}
+		number, wireType := int(key>>3), key&7
+		var value uint64
+		var b []byte
+		switch wireType {
+		case protoVarint:
+			value, err = d.nextUvarint()
+		case protoFixed64:
+			b, err = d.next(8)
+			if err == nil {
+				value = binary.LittleEndian.Uint64(b)
+			}
+		case protoFixed32:
+			b, err = d.next(4)
+			if err == nil {
+				value = uint64(binary.LittleEndian.Uint32(b))
+			}
+		case protoBytes:
+			value, err = d.nextUvarint()
+			if err == nil && value > uint64(d.Remaining()) {
+				err = errors.Errorf("length %d exceeds %d remaining bytes", value, d.Remaining())
+			}
+			if err == nil {
+				b, err = d.next(int(value))
+			}
+		default:
+			err = errors.Errorf("unsupported wire type %d", wireType)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "can't read field %d", number)
+		}
+		target, ok := fields[number]
+		if !ok {
+			continue
+		}
+		err = setProtoValue(target, wireType, value, b)
+		if err != nil {
+			return errors.Wrapf(err, "can't set field %d", number)
+		}
+	}
+	return nil
+}
+
+// nextUvarint reads unsigned varint
+func (d *Decoder) nextUvarint() (uint64, error) {
+	var result uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := d.next(1)
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b[0]&0x7f) << shift
+		if b[0] < 0x80 {
+			return result, nil
+		}
+	}
+	return 0, errors.New("varint overflows 64 bits")
+}
+
+// setProtoValue sets value of field with given wire type to target
+func setProtoValue(target interface{}, wireType, value uint64, b []byte) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.Errorf("target should be non-nil pointer, not %T", target)
+	}
+	v = v.Elem()
+	switch {
+	case wireType == protoBytes && v.Kind() == reflect.String:
+		v.SetString(string(b))
+	case wireType == protoBytes && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		result := make([]byte, len(b))
+		copy(result, b)
+		v.SetBytes(result)
+	case wireType == protoFixed64 && v.Kind() == reflect.Float64:
+		v.SetFloat(math.Float64frombits(value))
+	case wireType == protoFixed32 && v.Kind() == reflect.Float32:
+		v.SetFloat(float64(math.Float32frombits(uint32(value))))
+	case wireType == protoFixed32 && isSigned(v.Kind()):
+		v.SetInt(int64(int32(value)))
+	case wireType != protoBytes && isSigned(v.Kind()):
+		v.SetInt(int64(value))
+	case wireType != protoBytes && isInteger(v.Kind()):
+		v.SetUint(value)
+	case wireType == protoVarint && v.Kind() == reflect.Bool:
+		v.SetBool(value != 0)
+	default:
+		return errors.Errorf("can't set value of wire type %d to %v", wireType, v.Type())
+	}
+	return nil
+}
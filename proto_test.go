@@ -0,0 +1,53 @@
+package d2b
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeProtoLite(t *testing.T) {
+	Convey("Test DecodeProtoLite", t, func() {
+		// message { int32 id = 1; string name = 2; bool active = 3; int64 delta = 4; fixed32 crc = 5; double ratio = 6; bytes raw = 9 }
+		message := []byte{
+			0x08, 0x96, 0x01,
+			0x12, 0x05, 'h', 'e', 'l', 'l', 'o',
+			0x18, 0x01,
+			0x20, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01,
+			0x2d, 0x78, 0x56, 0x34, 0x12,
+			0x31, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f,
+			0x38, 0x07,
+			0x4a, 0x02, 0xca, 0xfe,
+		}
+		Convey("Should decode fields to targets", func() {
+			var (
+				id     int32
+				name   string
+				active bool
+				delta  int64
+				crc    uint32
+				ratio  float64
+				raw    []byte
+			)
+			err := DecodeProtoLite(message, map[int]interface{}{
+				1: &id, 2: &name, 3: &active, 4: &delta, 5: &crc, 6: &ratio, 9: &raw,
+			})
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, 150)
+			So(name, ShouldEqual, "hello")
+			So(active, ShouldBeTrue)
+			So(delta, ShouldEqual, -1)
+			So(crc, ShouldEqual, 0x12345678)
+			So(ratio, ShouldEqual, 1.5)
+			So(raw, ShouldResemble, []byte{0xca, 0xfe})
+		})
+		Convey("Should return error for mismatched target", func() {
+			var name uint32
+			So(DecodeProtoLite(message, map[int]interface{}{2: &name}), ShouldNotBeNil)
+		})
+		Convey("Should return error for truncated message", func() {
+			So(DecodeProtoLite(message[:5], map[int]interface{}{}), ShouldNotBeNil)
+			So(DecodeProtoLite([]byte{0x08, 0x96}, map[int]interface{}{}), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,62 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parsePString parses pstring option value: prefix width, optionally followed by "/inclusive", if length
+// counts prefix bytes too, e.g. "u16/inclusive"
+func parsePString(value string) (width int, inclusive bool, err error) {
+	parts := strings.Split(value, "/")
+	if len(parts) > 2 || len(parts) == 2 && parts[1] != "inclusive" {
+		return 0, false, errors.Errorf("bad pstring %q", value)
+	}
+	width, err = parseWidth(parts[0])
+	return width, len(parts) == 2, err
+}
+
+// decodePString reads length prefix of declared width, followed by string bytes
+func (d *Decoder) decodePString(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	prefix, err := d.next(tags.PString)
+	if err != nil {
+		return err
+	}
+	length := readUint(prefix, endian)
+	if tags.pstringInclusive {
+		if length < uint64(tags.PString) {
+			return errors.Errorf("length %d is less than its %d-byte prefix", length, tags.PString)
+		}
+		length -= uint64(tags.PString)
+	}
+	if length > uint64(d.maxStringLen()) {
+		return errors.Errorf("string length %d exceeds limit %d", length, d.maxStringLen())
+	}
+	b, err := d.next(int(length))
+	if err != nil {
+		return err
+	}
+	v.SetString(d.toStr(b))
+	return nil
+}
+
+// pstringToBytes writes string length of declared width, followed by string bytes
+func pstringToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	value := v.String()
+	length := uint64(len(value))
+	if ft.pstringInclusive {
+		length += uint64(ft.PString)
+	}
+	if ft.PString < 8 && length >= uint64(1)<<uint(8*ft.PString) {
+		return errors.Errorf("string length %d doesn't fit %d-byte prefix", length, ft.PString)
+	}
+	prefix := make([]byte, ft.PString)
+	putUint(prefix, endian, length)
+	buffer.Write(prefix)
+	buffer.WriteString(value)
+	return nil
+}
@@ -0,0 +1,60 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPString(t *testing.T) {
+	Convey("Test length prefixed strings", t, func() {
+		type Exclusive struct {
+			Name string `d2b:"pstring:u16"`
+			Tail uint8
+		}
+		type Inclusive struct {
+			Name string `d2b:"pstring:u16/inclusive"`
+			Tail uint8
+		}
+		Convey("Should decode and encode length without prefix", func() {
+			wire := []byte{0, 3, 'a', 'b', 'c', 7}
+			var result Exclusive
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Exclusive{Name: "abc", Tail: 7})
+			bytes, err := Encode(result, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should decode and encode length, which includes prefix", func() {
+			wire := []byte{0, 5, 'a', 'b', 'c', 7}
+			var result Inclusive
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Inclusive{Name: "abc", Tail: 7})
+			bytes, err := Encode(result, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should keep zero bytes of string", func() {
+			wire := []byte{0, 3, 'a', 0, 'c', 7}
+			var result Exclusive
+			err := Decode(wire, binary.BigEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Exclusive{Name: "a\x00c", Tail: 7})
+			bytes, err := Encode(result, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should return error if inclusive length is less than prefix", func() {
+			So(Decode([]byte{0, 1, 7}, binary.BigEndian, &Inclusive{}), ShouldNotBeNil)
+		})
+		Convey("Should return error for bad pstring option", func() {
+			type Bad struct {
+				Name string `d2b:"pstring:u16/exclusive"`
+			}
+			So(Decode([]byte{0, 0}, binary.BigEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
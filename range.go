@@ -0,0 +1,98 @@
+package d2b
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseRange parses byte range From:To of field with range option. To is exclusive
+func parseRange(value string) (from, to int, err error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("bad range %q, should be From:To", value)
+	}
+	from, err = strconv.Atoi(parts[0])
+	if err == nil {
+		to, err = strconv.Atoi(parts[1])
+	}
+	if err != nil || from < 0 || to <= from {
+		return 0, 0, errors.Errorf("bad range %q", value)
+	}
+	return from, to, nil
+}
+
+// checkRanges checks, that range of field doesn't overlap ranges of previous fields. String and slice fields
+// without length take the whole range
+func checkRanges(structType reflect.Type, tags []*structFieldTag, previous []int, tag *structFieldTag, fieldType reflect.Type) error {
+	if tag.Union {
+		return errors.New("range field can't be union")
+	}
+	for _, i := range previous {
+		if tags[i].Range != "" && tag.rangeFrom < tags[i].rangeTo && tags[i].rangeFrom < tag.rangeTo {
+			return errors.Errorf("range %s overlaps range %s of %s field", tag.Range, tags[i].Range, structType.Field(i).Name)
+		}
+	}
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if (fieldType.Kind() == reflect.String || fieldType.Kind() == reflect.Slice) && !tag.hasLength() {
+		tag.Length = tag.rangeTo - tag.rangeFrom
+	}
+	return nil
+}
+
+// checkRangeSize checks, that field took all bytes of its range
+func (t *structFieldTag) checkRangeSize(size int) error {
+	if size != t.rangeTo-t.rangeFrom {
+		return errors.Errorf("field takes %d bytes, but range %s has %d", size, t.Range, t.rangeTo-t.rangeFrom)
+	}
+	return nil
+}
+
+// checkRangeOverlaps checks, that range fields don't overlap bytes of previous non-range fields, including
+// union ones. Fields, which offsets or sizes depend on data, aren't checked
+func checkRangeOverlaps(structType reflect.Type, info *structInfo, tagKey string) error {
+	ranges := false
+	for _, tag := range info.tags {
+		ranges = ranges || tag.Range != ""
+	}
+	if !ranges {
+		return nil
+	}
+	p := &planner{encoder: &Encoder{TagKey: tagKey}}
+	type span struct{ field, from, to int }
+	var spans []span
+	var unionStart, unionEnd int
+	for _, i := range info.order {
+		tag := info.tags[i]
+		if tag.Skip {
+			continue
+		}
+		if tag.Range != "" {
+			for _, s := range spans {
+				if tag.rangeFrom < s.to && s.from < tag.rangeTo {
+					return errors.Errorf("range %s of %s field overlaps bytes %d:%d of %s field", tag.Range,
+						structType.Field(i).Name, s.from, s.to, structType.Field(s.field).Name)
+				}
+			}
+			unionStart = tag.rangeFrom
+		} else if !tag.Union {
+			unionStart = unionEnd
+		}
+		size, err := p.fieldSize(structType.Field(i).Type, tag)
+		if err != nil {
+			size = -1
+		}
+		end := addOffset(unionStart, size)
+		if tag.Range == "" && end != -1 && size != 0 {
+			spans = append(spans, span{field: i, from: unionStart, to: end})
+		}
+		if end == -1 || unionEnd != -1 && end > unionEnd {
+			unionEnd = end
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRange(t *testing.T) {
+	Convey("Test range option", t, func() {
+		type Record struct {
+			ID     uint32 `d2b:"range:4:8"`
+			Kind   uint16 `d2b:"range:0:2"`
+			Name   string `d2b:"range:10:13"`
+			Status uint8
+		}
+		record := Record{ID: 0x01020304, Kind: 7, Name: "abc", Status: 9}
+		data := []byte{7, 0, 0, 0, 4, 3, 2, 1, 0, 0, 'a', 'b', 'c', 9}
+		Convey("Should place fields at their byte ranges", func() {
+			b, err := Encode(record, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(b, ShouldResemble, data)
+		})
+		Convey("Should decode fields from their byte ranges", func() {
+			var decoded Record
+			err := Decode(data, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, record)
+		})
+		Convey("Should plan offsets of range fields", func() {
+			size, err := Size(record)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(data))
+			plans, err := Plan(record)
+			So(err, ShouldBeNil)
+			So(plans[0].Offset, ShouldEqual, 4)
+			So(plans[3].Offset, ShouldEqual, 13)
+		})
+		Convey("Should return error for overlapping ranges", func() {
+			type Bad struct {
+				A uint32 `d2b:"range:0:4"`
+				B uint16 `d2b:"range:2:4"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if range overlaps previous fields", func() {
+			type Bad struct {
+				X uint32
+				B uint16 `d2b:"range:0:2"`
+			}
+			So(Validate(Bad{}), ShouldNotBeNil)
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			var decoded Bad
+			So(Decode([]byte{1, 2, 3, 4, 5, 6}, binary.LittleEndian, &decoded), ShouldNotBeNil)
+			type Inner struct {
+				A uint16
+				B uint16
+			}
+			type BadNested struct {
+				Header Inner
+				C      uint8 `d2b:"range:3:4"`
+			}
+			_, err = Encode(BadNested{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			type BadUnion struct {
+				A uint16
+				B uint32 `d2b:"union:true"`
+				C uint8  `d2b:"range:3:4"`
+			}
+			So(Validate(BadUnion{}), ShouldNotBeNil)
+			type Good struct {
+				A uint16
+				B uint32 `d2b:"union:true"`
+				C uint8  `d2b:"range:4:5"`
+			}
+			So(Validate(Good{}), ShouldBeNil)
+			So(Validate(record), ShouldBeNil)
+		})
+		Convey("Should return error if field doesn't fit its range", func() {
+			type Bad struct {
+				A uint32 `d2b:"range:0:2"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+			var decoded Bad
+			err = Decode([]byte{1, 2, 3, 4}, binary.LittleEndian, &decoded)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package d2b
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// checkRawSelfType checks, that field with raw_self option is []byte
+func checkRawSelfType(t reflect.Type) error {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return errors.Errorf("raw_self field should be []byte, not %v", t)
+	}
+	return nil
+}
+
+// setRawSelf sets raw_self fields of decoded struct v to copy of struct bytes, which start at start offset
+// and end at the current one
+func (d *Decoder) setRawSelf(v reflect.Value, tags []*structFieldTag, start int) error {
+	for i, tag := range tags {
+		if !tag.RawSelf {
+			continue
+		}
+		if d.reader != nil {
+			return errors.New("raw bytes of struct can't be captured in reader")
+		}
+		offset := d.offset
+		d.offset = start
+		b, err := d.next(offset - start)
+		d.offset = offset
+		if err != nil {
+			return err
+		}
+		raw := reflect.MakeSlice(v.Field(i).Type(), len(b), len(b))
+		reflect.Copy(raw, reflect.ValueOf(b))
+		v.Field(i).Set(raw)
+	}
+	return nil
+}
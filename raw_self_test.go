@@ -0,0 +1,48 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRawSelf(t *testing.T) {
+	Convey("Test raw_self option", t, func() {
+		type Entry struct {
+			ID   uint16
+			Len  uint8
+			Data []byte `d2b:"length_from:Len"`
+			Raw  []byte `d2b:"raw_self:true"`
+		}
+		type Log struct {
+			Magic uint8
+			Entry Entry
+			Tail  uint8
+		}
+		data := []byte{0x7f, 1, 0, 2, 'h', 'i', 0xff}
+		Convey("Should capture bytes of decoded struct", func() {
+			var log Log
+			err := Decode(data, binary.LittleEndian, &log)
+			So(err, ShouldBeNil)
+			So(log.Entry.Raw, ShouldResemble, data[1:6])
+			So(log.Tail, ShouldEqual, 0xff)
+
+			data[1] = 9
+			So(log.Entry.Raw[0], ShouldEqual, 1)
+		})
+		Convey("Should ignore raw bytes on encoding", func() {
+			log := Log{Magic: 0x7f, Entry: Entry{ID: 1, Len: 2, Data: []byte("hi"), Raw: []byte{1, 2, 3}}, Tail: 0xff}
+			bytes, err := Encode(log, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0x7f, 1, 0, 2, 'h', 'i', 0xff})
+		})
+		Convey("Should return error for raw_self on non-byte slice", func() {
+			type Bad struct {
+				Raw []uint16 `d2b:"raw_self:true"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
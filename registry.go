@@ -0,0 +1,136 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var registryMx sync.RWMutex
+var registeredTypes = make(map[uint64]reflect.Type)
+var registeredIDs = make(map[reflect.Type]uint64)
+var registeredNamedTypes = make(map[string]reflect.Type)
+var registeredNames = make(map[reflect.Type]string)
+
+// registeredEndians contains map[reflect.Type]binary.ByteOrder, which is replaced on registration, so types
+// are looked up on every encoded and decoded value without locking
+var registeredEndians atomic.Value
+
+func init() {
+	registeredEndians.Store(map[reflect.Type]binary.ByteOrder{})
+}
+
+// RegisterType registers value's type with id. Interface struct fields with typeid tag option
+// are encoded with id of value's type and decoded to value of type, registered with read id.
+// Panics if id or type is already registered
+func RegisterType(id uint64, value interface{}) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		panic("d2b: can't register nil value")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	if registered, ok := registeredTypes[id]; ok && registered != t {
+		panic(fmt.Sprintf("d2b: id %d is already registered for %v", id, registered))
+	}
+	if registered, ok := registeredIDs[t]; ok && registered != id {
+		panic(fmt.Sprintf("d2b: type %v is already registered with id %d", t, registered))
+	}
+	registeredTypes[id] = t
+	registeredIDs[t] = id
+}
+
+func getRegisteredType(id uint64) (reflect.Type, bool) {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	t, ok := registeredTypes[id]
+	return t, ok
+}
+
+func getRegisteredID(t reflect.Type) (uint64, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	id, ok := registeredIDs[t]
+	return id, ok
+}
+
+// RegisterName registers value's type with name. Interface struct fields with typename_from tag option
+// are decoded to value of type, registered with name from the referenced string field.
+// Panics if name or type is already registered
+func RegisterName(name string, value interface{}) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		panic("d2b: can't register nil value")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	if registered, ok := registeredNamedTypes[name]; ok && registered != t {
+		panic(fmt.Sprintf("d2b: name %q is already registered for %v", name, registered))
+	}
+	if registered, ok := registeredNames[t]; ok && registered != name {
+		panic(fmt.Sprintf("d2b: type %v is already registered with name %q", t, registered))
+	}
+	registeredNamedTypes[name] = t
+	registeredNames[t] = name
+}
+
+func getNamedType(name string) (reflect.Type, bool) {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	t, ok := registeredNamedTypes[name]
+	return t, ok
+}
+
+func getRegisteredName(t reflect.Type) (string, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	name, ok := registeredNames[t]
+	return name, ok
+}
+
+// RegisterEndian pins byte order of value's type. Values of this type are always encoded and decoded
+// with given byte order, regardless of the endian of surrounding struct or field.
+// Panics if type is already registered with another byte order
+func RegisterEndian(value interface{}, endian binary.ByteOrder) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		panic("d2b: can't register nil value")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	endians := registeredEndians.Load().(map[reflect.Type]binary.ByteOrder)
+	if registered, ok := endians[t]; ok && registered != endian {
+		panic(fmt.Sprintf("d2b: type %v is already registered with %v byte order", t, registered))
+	}
+	updated := make(map[reflect.Type]binary.ByteOrder, len(endians)+1)
+	for registeredType, registered := range endians {
+		updated[registeredType] = registered
+	}
+	updated[t] = endian
+	registeredEndians.Store(updated)
+}
+
+// typeEndian returns byte order, registered for type t, or endian if it's not registered
+func typeEndian(t reflect.Type, endian binary.ByteOrder) binary.ByteOrder {
+	if registered, ok := registeredEndians.Load().(map[reflect.Type]binary.ByteOrder)[t]; ok {
+		return registered
+	}
+	return endian
+}
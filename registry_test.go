@@ -0,0 +1,126 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testCircle struct {
+	R uint8
+}
+
+func (c testCircle) Area() int32 { return 3 * int32(c.R) * int32(c.R) }
+
+func TestRegisterName(t *testing.T) {
+	RegisterName("square", testSquare{})
+	RegisterName("circle", &testCircle{})
+	Convey("Test interface fields with registered type names", t, func() {
+		type Struct struct {
+			NameLen uint8
+			Name    string    `d2b:"length_from:NameLen"`
+			Shape   testShape `d2b:"typename_from:Name"`
+		}
+		Convey("Should decode values of named types", func() {
+			var result Struct
+			err := Decode([]byte{6, 's', 'q', 'u', 'a', 'r', 'e', 2, 0, 0, 0}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Shape, ShouldResemble, testSquare{Side: 2})
+
+			err = Decode([]byte{6, 'c', 'i', 'r', 'c', 'l', 'e', 3}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result.Shape, ShouldResemble, testCircle{R: 3})
+		})
+		Convey("Should return error for unknown type name", func() {
+			var result Struct
+			err := Decode([]byte{3, 'b', 'o', 'x', 3}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should encode values of named types", func() {
+			bytes, err := Encode(Struct{NameLen: 6, Name: "circle", Shape: &testCircle{R: 3}}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{6, 'c', 'i', 'r', 'c', 'l', 'e', 3})
+
+			_, err = Encode(Struct{NameLen: 6, Name: "square", Shape: &testCircle{R: 3}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should panic on conflicting registration", func() {
+			So(func() { RegisterName("square", testCircle{}) }, ShouldPanic)
+			So(func() { RegisterType(1, testCircle{}) }, ShouldPanic)
+		})
+	})
+}
+
+type testTimestamp uint32
+
+func TestRegisterEndian(t *testing.T) {
+	RegisterEndian(testTimestamp(0), binary.BigEndian)
+	Convey("Test types with registered byte order", t, func() {
+		type Struct struct {
+			A    uint16
+			Time testTimestamp
+			B    [2]testTimestamp
+			C    *testTimestamp `d2b:"endian:little"`
+		}
+		c := testTimestamp(5)
+		data := Struct{A: 1, Time: 0x01020304, B: [2]testTimestamp{1, 2}, C: &c}
+		wire := []byte{1, 0, 1, 2, 3, 4, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 5}
+		Convey("Should decode type with registered byte order", func() {
+			var result Struct
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should encode type with registered byte order", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should panic if type is registered with another byte order", func() {
+			So(func() { RegisterEndian(testTimestamp(0), binary.LittleEndian) }, ShouldPanic)
+			So(func() { RegisterEndian(testTimestamp(0), binary.BigEndian) }, ShouldNotPanic)
+		})
+	})
+}
+
+func TestTypeIDFrom(t *testing.T) {
+	RegisterType(3, testCircle{})
+	Convey("Test interface fields, which type id is taken from previous field", t, func() {
+		type Event struct {
+			Kind  uint8
+			Shape testShape `d2b:"typeid_from:Kind"`
+		}
+		events := []Event{
+			{Kind: 1, Shape: testSquare{Side: 2}},
+			{Kind: 2, Shape: &testRect{W: 3, H: 4}},
+			{Kind: 3, Shape: testCircle{R: 5}},
+		}
+		wire := [][]byte{
+			{1, 2, 0, 0, 0},
+			{2, 3, 0, 4, 0},
+			{3, 5},
+		}
+		Convey("Should decode layouts, selected by discriminator", func() {
+			for i := range events {
+				var result Event
+				err := Decode(wire[i], binary.LittleEndian, &result)
+				So(err, ShouldBeNil)
+				So(result, ShouldResemble, events[i])
+			}
+			var result Event
+			So(Decode([]byte{9, 1}, binary.LittleEndian, &result), ShouldNotBeNil)
+		})
+		Convey("Should encode layouts without type id", func() {
+			for i := range events {
+				bytes, err := Encode(events[i], binary.LittleEndian)
+				So(err, ShouldBeNil)
+				So(bytes, ShouldResemble, wire[i])
+			}
+		})
+		Convey("Should return error if discriminator doesn't match value type", func() {
+			_, err := Encode(Event{Kind: 1, Shape: testCircle{R: 5}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,28 @@
+package d2b
+
+// ReservedRegion contains bytes of reserved region, which were skipped by decoder
+type ReservedRegion struct {
+	// Offset is a region offset from the beginning of data
+	Offset int
+	Bytes  []byte
+}
+
+// Reserved returns reserved regions, which were skipped by decoder with CaptureReserved option.
+// Reserved regions are blank (_) fields, padding of fixed_size structs and padding between slice elements with stride.
+// Regions are accumulated across Decode calls; regions of failed Decode call are dropped
+func (d *Decoder) Reserved() []ReservedRegion {
+	return d.reserved
+}
+
+// skipReserved skips n bytes of reserved region and captures them, if CaptureReserved option is set
+func (d *Decoder) skipReserved(n int) error {
+	offset := d.offset
+	b, err := d.next(n)
+	if err != nil || !d.CaptureReserved || n == 0 {
+		return err
+	}
+	region := ReservedRegion{Offset: offset, Bytes: make([]byte, n)}
+	copy(region.Bytes, b)
+	d.reserved = append(d.reserved, region)
+	return nil
+}
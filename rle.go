@@ -0,0 +1,92 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// decodeRLE decodes slice, stored as runs of (count, element) pairs. If slice length is declared, runs are
+// decoded until that many elements are expanded, otherwise until the end of data
+func (d *Decoder) decodeRLE(parent, v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	length := -1
+	if tags.hasLength() {
+		var err error
+		length, err = tags.length(parent)
+		if err != nil {
+			return err
+		}
+	} else if d.reader != nil {
+		return errors.New("rle field without length can't be decoded from reader")
+	}
+	result := reflect.MakeSlice(v.Type(), 0, 0)
+	for length != -1 && result.Len() < length || length == -1 && d.offset < d.length() {
+		b, err := d.next(tags.RLE)
+		if err != nil {
+			return err
+		}
+		count := readUint(b, endian)
+		if count == 0 {
+			return errors.Errorf("run at element %d has zero count", result.Len())
+		}
+		if length != -1 && count > uint64(length-result.Len()) {
+			return errors.Errorf("run of %d elements exceeds length %d", count, length)
+		}
+		// expanded elements are limited like strings, so corrupted counts don't exhaust memory
+		if count > uint64(d.maxStringLen()-result.Len()) {
+			return errors.Errorf("run of %d elements after %d expanded ones exceeds limit %d", count, result.Len(), d.maxStringLen())
+		}
+		value := reflect.New(v.Type().Elem()).Elem()
+		err = d.decodeValue(value, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't decode element %d", result.Len())
+		}
+		for i := uint64(0); i < count; i++ {
+			result = reflect.Append(result, value)
+		}
+	}
+	err := tags.checkCount(result.Len())
+	if err != nil {
+		return err
+	}
+	v.Set(result)
+	return nil
+}
+
+// rleToBytes writes runs of equal slice elements as (count, element) pairs
+func (e *Encoder) rleToBytes(parent, v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	if ft.hasLength() {
+		length, err := ft.length(parent)
+		if err != nil {
+			return err
+		}
+		if v.Len() != length {
+			return errors.Errorf("slice has %d elements, but its length is %d", v.Len(), length)
+		}
+	}
+	err := ft.checkCount(v.Len())
+	if err != nil {
+		return err
+	}
+	maxRun := uint64(1)<<uint(8*ft.RLE) - 1
+	if ft.RLE == 8 {
+		maxRun = ^uint64(0)
+	}
+	for i := 0; i < v.Len(); {
+		run := 1
+		for i+run < v.Len() && uint64(run) < maxRun && reflect.DeepEqual(v.Index(i).Interface(), v.Index(i+run).Interface()) {
+			run++
+		}
+		b := make([]byte, ft.RLE)
+		putUint(b, endian, uint64(run))
+		buffer.Write(b)
+		err = e.valueToBytes(v.Index(i), buffer, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't convert element %d to bytes", i)
+		}
+		i += run
+	}
+	return nil
+}
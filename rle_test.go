@@ -0,0 +1,70 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRLE(t *testing.T) {
+	Convey("Test run-length encoded slices", t, func() {
+		type Image struct {
+			Count  uint8
+			Pixels []uint16 `d2b:"rle:u8,length_from:Count"`
+			Mask   []uint8  `d2b:"rle:u8,byte_prefix:u8"`
+			Tail   []uint8  `d2b:"rle:u8,rest:true"`
+		}
+		data := Image{
+			Count:  6,
+			Pixels: []uint16{7, 7, 7, 7, 1, 7},
+			Mask:   []uint8{0, 0, 0, 0xff, 0xff},
+			Tail:   []uint8{5, 5},
+		}
+		wire := []byte{
+			6,
+			4, 7, 0, 1, 1, 0, 1, 7, 0,
+			4, 3, 0, 2, 0xff,
+			2, 5,
+		}
+		Convey("Should expand runs", func() {
+			var result Image
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, data)
+		})
+		Convey("Should merge equal elements to runs", func() {
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should split runs, which don't fit count width", func() {
+			type Long struct {
+				Values []uint8 `d2b:"rle:u8,rest:true"`
+			}
+			bytes, err := Encode(Long{Values: make([]uint8, 300)}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{255, 0, 45, 0})
+		})
+		Convey("Should return error if run exceeds length", func() {
+			var result Image
+			err := Decode([]byte{2, 3, 0, 7}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+			err = Decode([]byte{2, 0, 0, 7}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if expanded runs exceed limit", func() {
+			type Long struct {
+				Values []uint8 `d2b:"rle:u8,rest:true"`
+			}
+			decoder := NewDecoder([]byte{200, 1, 200, 2}, binary.LittleEndian)
+			decoder.MaxStringLen = 300
+			var result Long
+			So(decoder.Decode(&result), ShouldNotBeNil)
+			decoder = NewDecoder([]byte{200, 1, 100, 2}, binary.LittleEndian)
+			decoder.MaxStringLen = 300
+			So(decoder.Decode(&result), ShouldBeNil)
+			So(result.Values, ShouldHaveLength, 300)
+		})
+	})
+}
@@ -0,0 +1,52 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVariableLengthSections(t *testing.T) {
+	Convey("Test length_from option on slice of variable length structs", t, func() {
+		type Section struct {
+			Kind uint8
+			Data []byte `d2b:"byte_prefix:u8"`
+		}
+		type Frame struct {
+			SectionCount uint8
+			Sections     []Section `d2b:"length_from:SectionCount"`
+			Trailer      uint16
+		}
+		wire := []byte{3, 1, 2, 0xaa, 0xbb, 2, 0, 3, 3, 1, 2, 3, 0xca, 0xfe}
+		expected := Frame{
+			SectionCount: 3,
+			Sections: []Section{
+				{Kind: 1, Data: []byte{0xaa, 0xbb}},
+				{Kind: 2, Data: []byte{}},
+				{Kind: 3, Data: []byte{1, 2, 3}},
+			},
+			Trailer: 0xcafe,
+		}
+		Convey("Should decode declared count of sections", func() {
+			var frame Frame
+			err := Decode(wire, binary.BigEndian, &frame)
+			So(err, ShouldBeNil)
+			So(frame, ShouldResemble, expected)
+			bytes, err := Encode(frame, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should validate frame with variable length sections", func() {
+			So(Validate(Frame{}), ShouldBeNil)
+			plan, err := Plan(Frame{})
+			So(err, ShouldBeNil)
+			So(plan[1].Size, ShouldEqual, -1)
+		})
+		Convey("Should return error if data ends inside of section", func() {
+			var frame Frame
+			err := Decode(wire[:6], binary.BigEndian, &frame)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
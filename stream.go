@@ -0,0 +1,45 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"io"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// NewReaderDecoder returns decoder, which reads data from r
+func NewReaderDecoder(r io.Reader, endian binary.ByteOrder) *Decoder {
+	return &Decoder{reader: r, endian: endian}
+}
+
+// DecodeStream decodes records of template's type from r one by one and passes pointers to them to yield.
+// It returns nil, when r ends at record boundary, or error, returned by yield
+func DecodeStream(r io.Reader, endian binary.ByteOrder, template interface{}, yield func(interface{}) error) error {
+	t := reflect.TypeOf(template)
+	if t == nil {
+		return errors.New("template should not be nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	d := NewReaderDecoder(r, endian)
+	for {
+		start := d.offset
+		record := reflect.New(t)
+		err := d.decodeValue(record.Elem(), endian)
+		if err != nil {
+			if d.offset == start && errors.Cause(err) == io.EOF {
+				return nil
+			}
+			return errors.Wrapf(err, "can't decode record at offset %d", start)
+		}
+		if d.offset == start {
+			return errors.Errorf("%v record has zero length", t)
+		}
+		err = yield(record.Interface())
+		if err != nil {
+			return err
+		}
+	}
+}
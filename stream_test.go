@@ -0,0 +1,109 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeStream(t *testing.T) {
+	Convey("Test DecodeStream", t, func() {
+		type Record struct {
+			ID   uint16
+			Len  uint8
+			Name string `d2b:"length_from:Len"`
+		}
+		data := []byte{
+			1, 0, 1, 'a',
+			2, 0, 2, 'b', 'c',
+			3, 0, 0,
+		}
+		Convey("Should yield all records from reader", func() {
+			var records []*Record
+			err := DecodeStream(bytes.NewReader(data), binary.LittleEndian, Record{}, func(record interface{}) error {
+				records = append(records, record.(*Record))
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(records, ShouldResemble, []*Record{
+				{ID: 1, Len: 1, Name: "a"},
+				{ID: 2, Len: 2, Name: "bc"},
+				{ID: 3, Len: 0, Name: ""},
+			})
+		})
+		Convey("Should stop if yield returns error", func() {
+			stop := errors.New("stop")
+			count := 0
+			err := DecodeStream(bytes.NewReader(data), binary.LittleEndian, &Record{}, func(record interface{}) error {
+				count++
+				return stop
+			})
+			So(err, ShouldEqual, stop)
+			So(count, ShouldEqual, 1)
+		})
+		Convey("Should return error if reader ends inside record", func() {
+			count := 0
+			err := DecodeStream(bytes.NewReader(data[:7]), binary.LittleEndian, Record{}, func(record interface{}) error {
+				count++
+				return nil
+			})
+			So(err, ShouldNotBeNil)
+			So(count, ShouldEqual, 1)
+		})
+		Convey("Should leave reader decoder's offset after read bytes on error", func() {
+			decoder := NewReaderDecoder(bytes.NewReader(data[:7]), binary.LittleEndian)
+			So(decoder.Decode(&Record{}), ShouldBeNil)
+			So(decoder.Decode(&Record{}), ShouldNotBeNil)
+			So(decoder.Offset(), ShouldEqual, 7)
+		})
+		Convey("Should return error for zero length records", func() {
+			type Empty struct{}
+			err := DecodeStream(bytes.NewReader(data), binary.LittleEndian, Empty{}, func(record interface{}) error {
+				return nil
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestDecoderScratch(t *testing.T) {
+	Convey("Test reader decoder with scratch buffer", t, func() {
+		type Record struct {
+			ID   uint16
+			Len  uint8
+			Name string `d2b:"length_from:Len"`
+		}
+		decoder := NewReaderDecoder(bytes.NewReader([]byte{1, 0, 2, 'a', 'b', 2, 0, 3, 'c', 'd', 'e'}), binary.LittleEndian)
+		decoder.SetScratch(make([]byte, 2))
+		decoder.ZeroCopyStrings = true
+		var first, second Record
+		So(decoder.Decode(&first), ShouldBeNil)
+		So(decoder.Decode(&second), ShouldBeNil)
+		So(first, ShouldResemble, Record{ID: 1, Len: 2, Name: "ab"})
+		So(second, ShouldResemble, Record{ID: 2, Len: 3, Name: "cde"})
+	})
+}
+
+func BenchmarkDecodeReaderScratch(b *testing.B) {
+	type Record struct {
+		ID    uint32
+		Value int64
+		Flags [4]uint8
+	}
+	data := make([]byte, 16)
+	reader := bytes.NewReader(data)
+	decoder := NewReaderDecoder(reader, binary.LittleEndian)
+	decoder.SetScratch(make([]byte, 8))
+	var record Record
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(data)
+		err := decoder.Decode(&record)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// decodeElement decodes slice element. Interface elements are preceded by type id of width, declared
+// with typeid option. If stride option is set, padding after element is skipped, so the next element
+// starts stride bytes after the beginning of this one
+func (d *Decoder) decodeElement(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	start := d.offset
+	var err error
+	if v.Kind() == reflect.Interface && tags.TypeID != 0 {
+		err = d.decodeInterface(v, tags.TypeID, endian)
+	} else {
+		err = d.decodeValue(v, endian)
+	}
+	if err != nil || tags.Stride == 0 {
+		return err
+	}
+	padding := start + tags.Stride - d.offset
+	if padding < 0 {
+		return errors.Errorf("element size %d exceeds stride %d", d.offset-start, tags.Stride)
+	}
+	return d.skipReserved(padding)
+}
+
+// elementToBytes writes slice element. Interface elements are preceded by their type id. If stride option
+// is set, element is padded with zeros to stride bytes
+func (e *Encoder) elementToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	start := buffer.Len()
+	var err error
+	if v.Kind() == reflect.Interface && ft.TypeID != 0 {
+		err = e.interfaceToBytes(v, ft.TypeID, buffer, endian)
+	} else {
+		err = e.valueToBytes(v, buffer, endian)
+	}
+	if err != nil || ft.Stride == 0 {
+		return err
+	}
+	padding := start + ft.Stride - buffer.Len()
+	if padding < 0 {
+		return errors.Errorf("element size %d exceeds stride %d", buffer.Len()-start, ft.Stride)
+	}
+	buffer.Write(make([]byte, padding))
+	return nil
+}
@@ -1,26 +1,218 @@
 package d2b
 
 import (
+	"encoding/binary"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"sync"
 
 	"github.com/pkg/errors"
 )
 
+// DefaultTagKey is a default key of struct tags with encoding/decoding options
+const DefaultTagKey = "d2b"
+
+type structTagsKey struct {
+	structType reflect.Type
+	tagKey     string
+}
+
 var structsTagsMx sync.RWMutex
-var structsTags = make(map[reflect.Type][]*structFieldTag)
+var structsTags = make(map[structTagsKey]*structInfo)
+
+// structInfo contains parsed tags of struct fields and order, in which fields are encoded and decoded
+type structInfo struct {
+	tags  []*structFieldTag
+	order []int
+	// checksums is true if any field has crc32 option, so offsets of fields should be tracked
+	checksums bool
+}
 
 type structFieldTag struct {
-	Length int
-	Skip   bool
+	Length       int
+	LengthFrom   string
+	Min          int
+	Max          int
+	TypeID       int
+	GUID         string
+	Endian       binary.ByteOrder
+	Repeat       int
+	Duration     time.Duration
+	Width        int
+	Pairs        string
+	WordSwap     bool
+	Rest         bool
+	Union        bool
+	TypeNameFrom string
+	TypeIDFrom   string
+	Enum         []enumEntry
+	FixedSize    int
+	Fn           string
+	NullFlag     bool
+	Scale        float64
+	OffsetVal    float64
+	Saturate     bool
+	Bits         int
+	BitOrder     string
+	CountPrefix  int
+	Nibbles      bool
+	Mantissa     int
+	Exponent     int
+	Pad          string
+	Stride       int
+	Interleave   int
+	TotalLength  bool
+	BOM          bool
+	Order        int
+	ASCII7       string
+	Packed       bool
+	ASCII        bool
+	Justify      string
+	PString      int
+	CRC32        bool
+	CRCRange     string
+	CRCOver      string
+	OmitEmpty    bool
+	Since        int
+	Until        int
+	CountExpr    string
+	BitReverse   bool
+	TLV          int
+	TLVRest      bool
+	BytePrefix   int
+	LengthASCII  int
+	RLE          int
+	CurOffset    bool
+	LengthMethod string
+	ValuePrefix  int
+	HMAC         string
+	Varint       string
+	Range        string
+	Bitmap       bool
+	EndianFrom   string
+	Terminator   string
+	RawSelf      bool
+	Compress     string
+	WidthFrom    string
+	Decimal      int
+	EchoLength   string
+	AltEndian    []binary.ByteOrder
+	Transform    string
+	Flags        bool
+	Skip         bool
+
+	blank             bool
+	lengthFromIndex   int
+	typeNameFromIndex int
+	typeIDFromIndex   int
+	pairsIndex        int
+	pairsType         reflect.Type
+	interleaveIndex   int
+	interleaveTypes   []reflect.Type
+	pstringInclusive  bool
+	crcFromIndex      int
+	crcToIndex        int
+	crcOverIndex      int
+	countExpr         *expr
+	rangeFrom         int
+	rangeTo           int
+	bitOffset         int
+	bitGroup          []int
+	bitGroupLen       int
+	endianFromIndex   int
+	widthFromIndex    int
+	echoLengthIndex   int
+}
+
+// width returns integer width, declared with width option, or def if it's not set
+func (t *structFieldTag) width(def int) int {
+	if t.Width == 0 {
+		return def
+	}
+	return t.Width
+}
+
+// scaled returns true if float field is stored as integer, transformed with scale and offset_val options
+func (t *structFieldTag) scaled() bool {
+	return t.Scale != 0 || t.OffsetVal != 0
+}
+
+// scale returns scale of float field, stored as integer. Default scale is 1
+func (t *structFieldTag) scale() float64 {
+	if t.Scale == 0 {
+		return 1
+	}
+	return t.Scale
+}
+
+// present returns true if field is present in data of given version, according to since/until options
+func (t *structFieldTag) present(version int) bool {
+	return version >= t.Since && (t.Until == 0 || version <= t.Until)
+}
+
+// hasLength returns true if slice/string field length is specified
+func (t *structFieldTag) hasLength() bool {
+	return t.Length != 0 || t.LengthFrom != "" || t.LengthMethod != ""
+}
+
+// length returns slice/string field length. If length_from is specified, length is taken from
+// the parent struct field value. If length_method is specified, it's returned by the parent struct method
+func (t *structFieldTag) length(parent reflect.Value) (int, error) {
+	if t.LengthMethod != "" {
+		return methodLength(parent, t.LengthMethod)
+	}
+	if t.LengthFrom == "" {
+		return t.Length, nil
+	}
+	v := parent.Field(t.lengthFromIndex)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		l := v.Int()
+		if l < 0 {
+			return 0, errors.Errorf("%s field contains negative length %d", t.LengthFrom, l)
+		}
+		if uint64(l) > uint64(maxInt) {
+			return 0, errors.Errorf("%s field contains too big length %d", t.LengthFrom, l)
+		}
+		return int(l), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		l := v.Uint()
+		if l > uint64(maxInt) {
+			return 0, errors.Errorf("%s field contains too big length %d", t.LengthFrom, l)
+		}
+		return int(l), nil
+	}
+	return 0, errors.Errorf("%s field should be integer", t.LengthFrom)
+}
+
+// typeID returns type id, taken from the parent struct field, referenced by typeid_from option
+func (t *structFieldTag) typeID(parent reflect.Value) uint64 {
+	return uintValue(parent.Field(t.typeIDFromIndex))
 }
 
-func parseStructFieldTag(field reflect.StructField) (*structFieldTag, error) {
-	result := new(structFieldTag)
-	tag := field.Tag.Get("d2b")
+// checkCount checks, that slice elements count satisfies min/max options
+func (t *structFieldTag) checkCount(count int) error {
+	if count < t.Min {
+		return errors.Errorf("elements count %d is less than min %d", count, t.Min)
+	}
+	if t.Max != 0 && count > t.Max {
+		return errors.Errorf("elements count %d is greater than max %d", count, t.Max)
+	}
+	return nil
+}
+
+func parseStructFieldTag(field reflect.StructField, tagKey string) (*structFieldTag, error) {
+	result := &structFieldTag{Order: -1, TLV: -1, blank: field.Name == "_"}
+	tag := field.Tag.Get(tagKey)
 	parts := strings.Split(tag, ",")
 	for _, part := range parts {
 		part = strings.TrimSpace(part)
@@ -28,37 +220,655 @@ func parseStructFieldTag(field reflect.StructField) (*structFieldTag, error) {
 			result.Skip = true
 			continue
 		}
-		if strings.HasPrefix(part, "length:") {
-			sLength := strings.TrimPrefix(part, "length:")
-			length, err := strconv.Atoi(sLength)
-			if err != nil {
-				return nil, err
+		name, value := part, ""
+		if i := strings.Index(part, ":"); i != -1 {
+			name, value = part[:i], part[i+1:]
+		}
+		var err error
+		switch name {
+		case "length":
+			result.Length, err = strconv.Atoi(value)
+		case "length_from":
+			result.LengthFrom = value
+		case "length_method":
+			result.LengthMethod = value
+		case "min":
+			result.Min, err = strconv.Atoi(value)
+		case "max":
+			result.Max, err = strconv.Atoi(value)
+		case "typeid":
+			result.TypeID, err = parseWidth(value)
+		case "guid":
+			if value != GUIDLayoutMS && value != GUIDLayoutRFC4122 {
+				err = errors.Errorf("bad guid layout %q", value)
 			}
-			result.Length = length
-			continue
+			result.GUID = value
+		case "endian":
+			result.Endian, err = parseEndian(value)
+		case "repeat":
+			result.Repeat, err = strconv.Atoi(value)
+		case "duration":
+			result.Duration, err = parseDurationUnit(value)
+		case "width":
+			result.Width, err = strconv.Atoi(value)
+		case "pairs":
+			result.Pairs = value
+		case "wordswap":
+			result.WordSwap, err = strconv.ParseBool(value)
+		case "rest":
+			result.Rest, err = strconv.ParseBool(value)
+		case "union":
+			result.Union, err = strconv.ParseBool(value)
+		case "typename_from":
+			result.TypeNameFrom = value
+		case "typeid_from":
+			result.TypeIDFrom = value
+		case "enum":
+			result.Enum, err = parseEnum(value)
+		case "fixed_size":
+			result.FixedSize, err = strconv.Atoi(value)
+		case "fn":
+			result.Fn = value
+		case "null_flag":
+			result.NullFlag, err = strconv.ParseBool(value)
+		case "scale":
+			result.Scale, err = strconv.ParseFloat(value, 64)
+		case "offset_val":
+			result.OffsetVal, err = strconv.ParseFloat(value, 64)
+		case "saturate":
+			result.Saturate, err = strconv.ParseBool(value)
+		case "bits":
+			result.Bits, err = strconv.Atoi(value)
+		case "mantissa_exp":
+			result.Mantissa, result.Exponent, err = parseMantissaExp(value)
+		case "pad":
+			var pad uint64
+			pad, err = strconv.ParseUint(value, 0, 8)
+			result.Pad = string([]byte{byte(pad)})
+		case "packed":
+			result.Packed, err = strconv.ParseBool(value)
+		case "current_offset":
+			result.CurOffset, err = strconv.ParseBool(value)
+		case "rle":
+			result.RLE, err = parseWidth(value)
+		case "length_ascii":
+			result.LengthASCII, err = strconv.Atoi(value)
+		case "byte_prefix":
+			result.BytePrefix, err = parseWidth(value)
+		case "tlv":
+			var tlv uint64
+			tlv, err = strconv.ParseUint(value, 0, 31)
+			result.TLV = int(tlv)
+		case "tlv_rest":
+			result.TLVRest, err = strconv.ParseBool(value)
+		case "bitreverse":
+			result.BitReverse, err = strconv.ParseBool(value)
+		case "count_expr":
+			result.CountExpr = value
+			result.countExpr, err = parseExpr(value)
+		case "since":
+			result.Since, err = strconv.Atoi(value)
+		case "until":
+			result.Until, err = strconv.Atoi(value)
+		case "omit_empty":
+			result.OmitEmpty, err = strconv.ParseBool(value)
+		case "crc32":
+			result.CRC32, err = strconv.ParseBool(value)
+		case "crc_range":
+			result.CRCRange = value
+		case "crc_over":
+			result.CRCOver = value
+		case "pstring":
+			result.PString, result.pstringInclusive, err = parsePString(value)
+		case "ascii":
+			result.ASCII, err = strconv.ParseBool(value)
+		case "justify":
+			result.Justify = value
+		case "ascii7":
+			result.ASCII7, err = parseASCII7(value)
+		case "order":
+			result.Order, err = strconv.Atoi(value)
+		case "bom":
+			result.BOM, err = strconv.ParseBool(value)
+		case "total_length":
+			result.TotalLength, err = strconv.ParseBool(value)
+		case "interleave":
+			result.Interleave, err = strconv.Atoi(value)
+		case "stride":
+			result.Stride, err = strconv.Atoi(value)
+		case "nibbles":
+			result.Nibbles, err = strconv.ParseBool(value)
+		case "count_prefix":
+			result.CountPrefix, err = parseWidth(value)
+		case "compress":
+			result.Compress = value
+		case "raw_self":
+			result.RawSelf, err = strconv.ParseBool(value)
+		case "terminator":
+			result.Terminator = value
+		case "endian_from":
+			result.EndianFrom = value
+		case "elem_width_from":
+			result.WidthFrom = value
+		case "endian_alternate":
+			result.AltEndian, err = parseAlternateEndian(value)
+		case "flags":
+			result.Flags, err = strconv.ParseBool(value)
+		case "transform":
+			result.Transform = value
+		case "echo_length":
+			result.EchoLength = value
+		case "decimal":
+			result.Decimal, err = strconv.Atoi(value)
+		case "bitmap":
+			result.Bitmap, err = strconv.ParseBool(value)
+		case "range":
+			result.Range = value
+			result.rangeFrom, result.rangeTo, err = parseRange(value)
+		case "varint":
+			result.Varint = value
+		case "hmac":
+			result.HMAC = value
+		case "value_prefix":
+			result.ValuePrefix, err = parseWidth(value)
+		case "bit_order":
+			if value != BitOrderLSB && value != BitOrderMSB {
+				err = errors.Errorf("bad bit order %q", value)
+			}
+			result.BitOrder = value
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
 	return result, nil
 }
 
-func getStructTags(structType reflect.Type) ([]*structFieldTag, error) {
+// structFixedSize returns struct size, declared with fixed_size option of blank field, or 0 if it's not declared
+func structFixedSize(tags []*structFieldTag) int {
+	for _, tag := range tags {
+		if tag.FixedSize != 0 {
+			return tag.FixedSize
+		}
+	}
+	return 0
+}
+
+// parseWidth parses unsigned integer width names: u8, u16, u32, u64
+func parseWidth(name string) (int, error) {
+	switch name {
+	case "u8":
+		return 1, nil
+	case "u16":
+		return 2, nil
+	case "u32":
+		return 4, nil
+	case "u64":
+		return 8, nil
+	}
+	return 0, errors.Errorf("bad width %q", name)
+}
+
+// parseEndian parses byte order names: big, little
+func parseEndian(name string) (binary.ByteOrder, error) {
+	switch name {
+	case "big":
+		return binary.BigEndian, nil
+	case "little":
+		return binary.LittleEndian, nil
+	}
+	return nil, errors.Errorf("bad endian %q", name)
+}
+
+// checkRepeat checks, that field with repeat option is array of declared length
+func checkRepeat(t reflect.Type, repeat int) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Array {
+		return errors.Errorf("repeated field should be array, not %v", t)
+	}
+	if t.Len() != repeat {
+		return errors.Errorf("repeated field array length %d doesn't match repeat %d", t.Len(), repeat)
+	}
+	return nil
+}
+
+// resolveTypeNameFrom checks, that typename_from refers to one of the previous string fields
+func resolveTypeNameFrom(structType reflect.Type, previous []int, tag *structFieldTag) error {
+	for _, i := range previous {
+		ft := structType.Field(i)
+		if ft.Name != tag.TypeNameFrom {
+			continue
+		}
+		if ft.Type.Kind() != reflect.String {
+			return errors.Errorf("typename_from field %s should be string", tag.TypeNameFrom)
+		}
+		tag.typeNameFromIndex = i
+		return nil
+	}
+	return errors.Errorf("typename_from field %s should be declared before", tag.TypeNameFrom)
+}
+
+// resolveLengthFrom checks, that length_from refers to one of the previous integer fields
+func resolveLengthFrom(structType reflect.Type, previous []int, tag *structFieldTag) (err error) {
+	tag.lengthFromIndex, err = resolveIntegerField(structType, previous, "length_from", tag.LengthFrom)
+	return err
+}
+
+// resolveTypeIDFrom checks, that typeid_from refers to one of the previous integer fields
+func resolveTypeIDFrom(structType reflect.Type, previous []int, tag *structFieldTag) (err error) {
+	tag.typeIDFromIndex, err = resolveIntegerField(structType, previous, "typeid_from", tag.TypeIDFrom)
+	return err
+}
+
+// resolveIntegerField returns index of integer field with given name, which is one of previous fields
+func resolveIntegerField(structType reflect.Type, previous []int, option, name string) (int, error) {
+	for _, i := range previous {
+		ft := structType.Field(i)
+		if ft.Name != name {
+			continue
+		}
+		t := ft.Type
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return i, nil
+		}
+		return 0, errors.Errorf("%s field %s should be integer", option, name)
+	}
+	return 0, errors.Errorf("%s field %s should be declared before", option, name)
+}
+
+// getStructTags returns parsed tags of struct fields. DefaultTagKey is used if tagKey is empty
+func getStructTags(structType reflect.Type, tagKey string) ([]*structFieldTag, error) {
+	info, err := getStructInfo(structType, tagKey)
+	if err != nil {
+		return nil, err
+	}
+	return info.tags, nil
+}
+
+// structOrder returns indexes of struct fields, sorted by order option, or in declaration order,
+// if it's not declared. Orders of fields, which aren't skipped with "-", should be 0, 1, 2, ...
+func structOrder(structType reflect.Type, tags []*structFieldTag) ([]int, error) {
+	var declared int
+	for _, tag := range tags {
+		if tag.Order != -1 {
+			declared++
+		}
+	}
+	order := make([]int, 0, len(tags))
+	if declared == 0 {
+		for i := range tags {
+			order = append(order, i)
+		}
+		return order, nil
+	}
+	positions := make([]int, len(tags))
+	for i := range positions {
+		positions[i] = -1
+	}
+	var skipped []int
+	for i, tag := range tags {
+		if tag.Order == -1 {
+			if !tag.Skip {
+				return nil, errors.Errorf("%v field should declare order, because other fields declare it", structType.Field(i).Name)
+			}
+			skipped = append(skipped, i)
+			continue
+		}
+		if tag.Order < 0 || tag.Order >= declared {
+			return nil, errors.Errorf("%v field order %d is out of range [0, %d)", structType.Field(i).Name, tag.Order, declared)
+		}
+		if positions[tag.Order] != -1 {
+			return nil, errors.Errorf("%v field order %d is already declared by %v field", structType.Field(i).Name, tag.Order, structType.Field(positions[tag.Order]).Name)
+		}
+		positions[tag.Order] = i
+	}
+	order = append(order, positions[:declared]...)
+	return append(order, skipped...), nil
+}
+
+// getStructInfo returns parsed tags and fields order of struct. DefaultTagKey is used if tagKey is empty
+func getStructInfo(structType reflect.Type, tagKey string) (*structInfo, error) {
+	if tagKey == "" {
+		tagKey = DefaultTagKey
+	}
+	key := structTagsKey{structType: structType, tagKey: tagKey}
 	structsTagsMx.RLock()
-	if tags, ok := structsTags[structType]; ok {
+	if info, ok := structsTags[key]; ok {
 		structsTagsMx.RUnlock()
-		return tags, nil
+		return info, nil
 	}
 	structsTagsMx.RUnlock()
+	info, err := parseStructInfo(structType, tagKey)
+	if err != nil {
+		return nil, err
+	}
+	// ranges are checked against sizes of other fields, which may be structs, so the lock isn't held
+	err = checkRangeOverlaps(structType, info, tagKey)
+	if err != nil {
+		return nil, err
+	}
 	structsTagsMx.Lock()
 	defer structsTagsMx.Unlock()
+	if cached, ok := structsTags[key]; ok {
+		return cached, nil
+	}
+	structsTags[key] = info
+	return info, nil
+}
+
+// parseStructInfo parses tags of struct fields and resolves fields, which they refer to
+func parseStructInfo(structType reflect.Type, tagKey string) (*structInfo, error) {
 	tags := make([]*structFieldTag, structType.NumField())
 	for i := 0; i < structType.NumField(); i++ {
-		ft := structType.Field(i)
-		tag, err := parseStructFieldTag(structType.Field(i))
+		tag, err := parseStructFieldTag(structType.Field(i), tagKey)
 		if err != nil {
-			return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			return nil, errors.Wrapf(err, "%v field tag error", structType.Field(i).Name)
 		}
 		tags[i] = tag
 	}
-	structsTags[structType] = tags
-	return structsTags[structType], nil
+	order, err := structOrder(structType, tags)
+	if err != nil {
+		return nil, err
+	}
+	var checksums, omitting bool
+	for position, i := range order {
+		ft := structType.Field(i)
+		tag := tags[i]
+		if tag.OmitEmpty {
+			omitting = true
+		} else if omitting && !tag.Skip {
+			return nil, errors.Errorf("%v field should be omit_empty, because it follows omit_empty field", ft.Name)
+		}
+		previous := order[:position]
+		if tag.LengthFrom != "" {
+			err = resolveLengthFrom(structType, previous, tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.LengthMethod != "" {
+			err = checkLengthMethod(structType, tag.LengthMethod)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.EndianFrom != "" {
+			tag.endianFromIndex, err = resolveEndianFrom(structType, previous, tag.EndianFrom)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.WidthFrom != "" {
+			err = checkElemWidth(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+			tag.widthFromIndex, err = resolveIntegerField(structType, previous, "elem_width_from", tag.WidthFrom)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.EchoLength != "" {
+			err = checkTotalLengthType(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+			tag.echoLengthIndex, err = resolveIntegerField(structType, previous, "echo_length", tag.EchoLength)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.TypeNameFrom != "" {
+			err = resolveTypeNameFrom(structType, previous, tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.TypeIDFrom != "" {
+			err = resolveTypeIDFrom(structType, previous, tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.countExpr != nil {
+			err = tag.countExpr.resolve(structType, previous)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.CRCRange != "" {
+			if !tag.CRC32 {
+				return nil, errors.Errorf("%v field tag error: crc_range should be declared with crc32 option", ft.Name)
+			}
+			err = resolveCRCRange(structType, previous, tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.CRCOver != "" {
+			if !tag.CRC32 || tag.CRCRange != "" {
+				return nil, errors.Errorf("%v field tag error: crc_over should be declared with crc32 option and without crc_range", ft.Name)
+			}
+			err = resolveCRCOver(structType, order[position+1:], tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if len(tag.AltEndian) != 0 {
+			err = checkAlternateEndian(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Decimal != 0 {
+			err = checkDecimal(ft.Type, tag.Decimal)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Compress != "" {
+			err = checkCompressed(tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.RawSelf {
+			err = checkRawSelfType(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Terminator != "" {
+			err = checkTerminator(ft.Type, tag.Terminator)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Bitmap {
+			err = checkBitmap(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Range != "" {
+			err = checkRanges(structType, tags, previous, tag, ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Varint != "" {
+			err = checkVarint(ft.Type, tag.Varint)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.HMAC != "" {
+			for _, j := range order[position+1:] {
+				if !tags[j].Skip {
+					return nil, errors.Errorf("%v field tag error: hmac field should be the last one", ft.Name)
+				}
+			}
+			err = checkHMACType(ft.Type, tag.HMAC)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.CRC32 {
+			checksums = true
+			err = checkCRCType(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		switch tag.Width {
+		case 0, 1, 2, 4, 8:
+		default:
+			return nil, errors.Errorf("%v field tag error: bad width %d", ft.Name, tag.Width)
+		}
+		if tag.Min < 0 || tag.Max != 0 && tag.Max < tag.Min {
+			return nil, errors.Errorf("%v field tag error: bad elements count range min %d, max %d", ft.Name, tag.Min, tag.Max)
+		}
+		if tag.Enum != nil {
+			err = checkEnumWidth(ft.Type, tag.Enum, tag.width(1))
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Repeat != 0 {
+			err = checkRepeat(ft.Type, tag.Repeat)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Duration != 0 {
+			err = checkDuration(ft.Type, tag.Width)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.FixedSize != 0 && ft.Name != "_" {
+			return nil, errors.Errorf("%v field tag error: fixed_size can be declared only on blank (_) field", ft.Name)
+		}
+		if tag.Packed && ft.Name != "_" {
+			return nil, errors.Errorf("%v field tag error: packed can be declared only on blank (_) field", ft.Name)
+		}
+		if tag.Union && position == 0 {
+			return nil, errors.Errorf("%v field can't share offset with previous field, because it's the first one", ft.Name)
+		}
+		if tag.Flags {
+			err = checkFlags(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.NullFlag {
+			_, _, err = nullableFields(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.scaled() {
+			err = checkFloat(ft.Type, "scale")
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Bits != 0 {
+			err = checkBits(ft.Type, tag.Bits)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Mantissa != 0 {
+			err = checkFloat(ft.Type, "mantissa_exp")
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.BOM {
+			err = checkBOM(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.TotalLength {
+			err = checkTotalLengthType(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.Nibbles {
+			err = checkNibbles(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.ASCII {
+			err = checkASCIINumber(ft.Type, tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.LengthASCII != 0 {
+			err = checkASCIILength(ft.Type, tag.LengthASCII)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.CurOffset {
+			err = checkCurrentOffsetType(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.TLVRest {
+			err = checkTLVRest(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		} else if !tag.Skip {
+			err = checkMapType(ft.Type, tag)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.BitReverse {
+			err = checkBitReverse(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+		if tag.WordSwap {
+			err = checkWordSwap(ft.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", ft.Name)
+			}
+		}
+	}
+	for i, tag := range tags {
+		if tag.Pairs != "" {
+			err := resolvePairs(structType, i, tags)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", structType.Field(i).Name)
+			}
+		}
+		if tag.Interleave != 0 {
+			err := resolveInterleave(structType, i, tags)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v field tag error", structType.Field(i).Name)
+			}
+		}
+	}
+	err = groupBitFields(structType, tags, order)
+	if err != nil {
+		return nil, err
+	}
+	return &structInfo{tags: tags, order: order, checksums: checksums}, nil
 }
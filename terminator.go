@@ -0,0 +1,73 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// TerminatorZero is a value of terminator option. Slice ends with element, which has zero value, e.g.
+// all-zero record
+const TerminatorZero = "zero"
+
+// checkTerminator checks terminator option of slice field
+func checkTerminator(t reflect.Type, terminator string) error {
+	if terminator != TerminatorZero {
+		return errors.Errorf("bad terminator %q", terminator)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice {
+		return errors.Errorf("field with terminator option should be slice, not %v", t)
+	}
+	return nil
+}
+
+// decodeTerminated decodes slice elements until element with zero value. Terminating element is consumed,
+// but isn't appended to slice
+func (d *Decoder) decodeTerminated(v reflect.Value, tags *structFieldTag, endian binary.ByteOrder) error {
+	result := reflect.MakeSlice(v.Type(), 0, 0)
+	for {
+		start := d.offset
+		value := reflect.New(v.Type().Elem()).Elem()
+		err := d.decodeElement(value, tags, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't decode element %d", result.Len())
+		}
+		if isZero(value) {
+			break
+		}
+		if d.offset == start {
+			return errors.New("can't decode zero length elements before terminator")
+		}
+		result = reflect.Append(result, value)
+	}
+	err := tags.checkCount(result.Len())
+	if err != nil {
+		return err
+	}
+	v.Set(result)
+	return nil
+}
+
+// terminatedToBytes writes slice elements, followed by zero element. Zero elements can't be written
+// before it, because they would end slice on decoding
+func (e *Encoder) terminatedToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer, endian binary.ByteOrder) error {
+	err := ft.checkCount(v.Len())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if isZero(v.Index(i)) {
+			return errors.Errorf("element %d is zero, but zero element terminates slice", i)
+		}
+		err = e.elementToBytes(v.Index(i), ft, buffer, endian)
+		if err != nil {
+			return errors.Wrapf(err, "can't convert element %d to bytes", i)
+		}
+	}
+	return e.elementToBytes(reflect.Zero(v.Type().Elem()), ft, buffer, endian)
+}
@@ -0,0 +1,43 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTerminator(t *testing.T) {
+	Convey("Test terminator option", t, func() {
+		type Record struct {
+			ID    uint16
+			Value uint8
+		}
+		type Table struct {
+			Records []Record `d2b:"terminator:zero"`
+			Tail    uint8
+		}
+		table := Table{Records: []Record{{ID: 1, Value: 10}, {ID: 2, Value: 0}, {ID: 0, Value: 30}}, Tail: 0xff}
+		data := []byte{1, 0, 10, 2, 0, 0, 0, 0, 30, 0, 0, 0, 0xff}
+		Convey("Should decode records until zero record", func() {
+			var decoded Table
+			err := Decode(data, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, table)
+		})
+		Convey("Should write zero record after records", func() {
+			bytes, err := Encode(table, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, data)
+		})
+		Convey("Should return error for zero record before terminator", func() {
+			_, err := Encode(Table{Records: []Record{{}, {ID: 1}}}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if data ends before terminator", func() {
+			var decoded Table
+			err := Decode(data[:9], binary.LittleEndian, &decoded)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
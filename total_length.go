@@ -0,0 +1,69 @@
+package d2b
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// totalLengthField returns index of struct field with total_length option or -1 if there is no such field
+func totalLengthField(tags []*structFieldTag) int {
+	for i, tag := range tags {
+		if tag.TotalLength {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkTotalLength checks, that value of total_length field v matches count of decoded struct bytes
+func checkTotalLength(v reflect.Value, length int) error {
+	if value := uintValue(v); value != uint64(length) {
+		return errors.Errorf("total length field contains %d, but struct takes %d bytes", value, length)
+	}
+	return nil
+}
+
+// patchTotalLength overwrites bytes of total_length field, which were written at [start:end), with
+// count of struct bytes
+func patchTotalLength(buffer *bytes.Buffer, start, end, length int, endian binary.ByteOrder) error {
+	width := end - start
+	if width < 8 && uint64(length) >= uint64(1)<<uint(8*width) {
+		return errors.Errorf("total length %d doesn't fit %d-byte field", length, width)
+	}
+	putUint(buffer.Bytes()[start:end], endian, uint64(length))
+	return nil
+}
+
+// checkTotalLengthType checks, that field with total_length option is integer
+func checkTotalLengthType(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !isInteger(t.Kind()) {
+		return errors.Errorf("total length field should be integer, not %v", t)
+	}
+	return nil
+}
+
+// echoLengthField returns index of struct field with echo_length option or -1 if there is no such field
+func echoLengthField(tags []*structFieldTag) int {
+	for i, tag := range tags {
+		if tag.EchoLength != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkEchoLength checks, that value of echo_length field equals to value of leading length field and
+// count of decoded struct bytes
+func checkEchoLength(parent reflect.Value, i int, tag *structFieldTag, length int) error {
+	echo, leading := uintValue(parent.Field(i)), uintValue(parent.Field(tag.echoLengthIndex))
+	if echo != leading {
+		return errors.Errorf("trailing length %d doesn't match %s field value %d", echo, tag.EchoLength, leading)
+	}
+	return checkTotalLength(parent.Field(i), length)
+}
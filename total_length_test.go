@@ -0,0 +1,81 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTotalLength(t *testing.T) {
+	Convey("Test total length fields", t, func() {
+		type Message struct {
+			Length  uint16 `d2b:"total_length:true,endian:big"`
+			Kind    uint8
+			Count   uint8
+			Payload []byte `d2b:"length_from:Count"`
+		}
+		wire := []byte{0, 7, 1, 3, 'a', 'b', 'c'}
+		Convey("Should patch total length after encoding", func() {
+			bytes, err := Encode(Message{Length: 100, Kind: 1, Count: 3, Payload: []byte("abc")}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should check total length after decoding", func() {
+			var result Message
+			err := Decode(wire, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Message{Length: 7, Kind: 1, Count: 3, Payload: []byte("abc")})
+
+			err = Decode([]byte{0, 8, 1, 3, 'a', 'b', 'c', 0}, binary.LittleEndian, &result)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if total length doesn't fit field", func() {
+			type Small struct {
+				Length uint8  `d2b:"total_length:true"`
+				Data   []byte `d2b:"length:255"`
+			}
+			_, err := Encode(Small{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestEchoLength(t *testing.T) {
+	Convey("Test echo length fields", t, func() {
+		type Frame struct {
+			Length  uint16 `d2b:"total_length:true"`
+			Payload []byte `d2b:"length:3"`
+			Echo    uint16 `d2b:"echo_length:Length"`
+		}
+		wire := []byte{0, 7, 'a', 'b', 'c', 0, 7}
+		Convey("Should set trailing length on encoding", func() {
+			bytes, err := Encode(Frame{Payload: []byte("abc")}, binary.BigEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should check trailing length on decoding", func() {
+			var frame Frame
+			err := Decode(wire, binary.BigEndian, &frame)
+			So(err, ShouldBeNil)
+			So(frame, ShouldResemble, Frame{Length: 7, Payload: []byte("abc"), Echo: 7})
+		})
+		Convey("Should return error for tampered trailing length", func() {
+			var frame Frame
+			err := Decode([]byte{0, 7, 'a', 'b', 'c', 0, 6}, binary.BigEndian, &frame)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if lengths don't match frame size", func() {
+			type Loose struct {
+				Length  uint8
+				Payload []byte `d2b:"length:3"`
+				Echo    uint8  `d2b:"echo_length:Length"`
+			}
+			var loose Loose
+			err := Decode([]byte{4, 'a', 'b', 'c', 4}, binary.BigEndian, &loose)
+			So(err, ShouldNotBeNil)
+			err = Decode([]byte{5, 'a', 'b', 'c', 5}, binary.BigEndian, &loose)
+			So(err, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package d2b
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// transform decodes and encodes fields with transform option
+type transform struct {
+	decode func([]byte) (interface{}, int, error)
+	encode func(interface{}) ([]byte, error)
+}
+
+var transformsMx sync.RWMutex
+var transforms = make(map[string]transform)
+
+// RegisterTransform registers named codec, which is used by fields with transform:name option.
+// decode receives all bytes, which are left, and returns field value and count of consumed bytes.
+// encode receives field value and returns its bytes
+func RegisterTransform(name string, decode func([]byte) (interface{}, int, error), encode func(interface{}) ([]byte, error)) {
+	transformsMx.Lock()
+	defer transformsMx.Unlock()
+	transforms[name] = transform{decode: decode, encode: encode}
+}
+
+func getTransform(name string) (transform, error) {
+	transformsMx.RLock()
+	defer transformsMx.RUnlock()
+	t, ok := transforms[name]
+	if !ok {
+		return transform{}, errors.Errorf("transform %q is not registered", name)
+	}
+	return t, nil
+}
+
+// decodeTransformed decodes field v with registered transform. Returned value should be assignable
+// or convertible to field type
+func (d *Decoder) decodeTransformed(v reflect.Value, tags *structFieldTag) error {
+	if d.reader != nil {
+		return errors.New("fields with transform option can't be decoded from reader")
+	}
+	t, err := getTransform(tags.Transform)
+	if err != nil {
+		return err
+	}
+	start := d.offset
+	rest, _ := d.next(d.length() - d.offset)
+	d.offset = start
+	value, n, err := t.decode(rest)
+	if err != nil {
+		return errors.Wrapf(err, "%s transform error", tags.Transform)
+	}
+	if n < 0 || n > len(rest) {
+		return errors.Errorf("%s transform consumed %d bytes of %d", tags.Transform, n, len(rest))
+	}
+	result := reflect.ValueOf(value)
+	switch {
+	case !result.IsValid():
+		result = reflect.Zero(v.Type())
+	case result.Type().AssignableTo(v.Type()):
+	case result.Type().ConvertibleTo(v.Type()):
+		result = result.Convert(v.Type())
+	default:
+		return errors.Errorf("%s transform returned %v, which can't be set to %v field", tags.Transform, result.Type(), v.Type())
+	}
+	v.Set(result)
+	d.offset += n
+	return nil
+}
+
+// transformToBytes encodes field v with registered transform
+func transformToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) error {
+	t, err := getTransform(ft.Transform)
+	if err != nil {
+		return err
+	}
+	b, err := t.encode(v.Interface())
+	if err != nil {
+		return errors.Wrapf(err, "%s transform error", ft.Transform)
+	}
+	buffer.Write(b)
+	return nil
+}
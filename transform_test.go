@@ -0,0 +1,85 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// rot13 rotates latin letters of s by 13 positions
+func rot13(s []byte) []byte {
+	result := make([]byte, len(s))
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z':
+			c = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			c = 'A' + (c-'A'+13)%26
+		}
+		result[i] = c
+	}
+	return result
+}
+
+func init() {
+	// rot13 transform stores string, prefixed with its length, with rotated letters
+	RegisterTransform("rot13", func(b []byte) (interface{}, int, error) {
+		if len(b) == 0 || len(b) < 1+int(b[0]) {
+			return nil, 0, errors.New("not enough bytes")
+		}
+		return string(rot13(b[1 : 1+b[0]])), 1 + int(b[0]), nil
+	}, func(value interface{}) ([]byte, error) {
+		s := reflect.ValueOf(value).String()
+		if len(s) > 255 {
+			return nil, errors.New("too long string")
+		}
+		return append([]byte{byte(len(s))}, rot13([]byte(s))...), nil
+	})
+}
+
+func TestTransform(t *testing.T) {
+	Convey("Test transform option", t, func() {
+		type Name string
+		type Message struct {
+			Kind   uint8
+			Secret string `d2b:"transform:rot13"`
+			Alias  Name   `d2b:"transform:rot13"`
+			Tail   uint8
+		}
+		message := Message{Kind: 1, Secret: "Hello", Alias: "abc", Tail: 2}
+		wire := []byte{1, 5, 'U', 'r', 'y', 'y', 'b', 3, 'n', 'o', 'p', 2}
+		Convey("Should encode fields with registered transform", func() {
+			bytes, err := Encode(message, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, wire)
+		})
+		Convey("Should decode fields with registered transform", func() {
+			var decoded Message
+			err := Decode(wire, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, message)
+		})
+		Convey("Should return transform errors", func() {
+			var decoded Message
+			err := Decode(wire[:4], binary.LittleEndian, &decoded)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error if value can't be set to field", func() {
+			var decoded struct {
+				Value uint16 `d2b:"transform:rot13"`
+			}
+			err := Decode(wire[1:], binary.LittleEndian, &decoded)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should return error for unregistered transform", func() {
+			type Bad struct {
+				Value string `d2b:"transform:unknown"`
+			}
+			_, err := Encode(Bad{}, binary.LittleEndian)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnion(t *testing.T) {
+	Convey("Test union fields", t, func() {
+		type Struct struct {
+			Tag   uint8
+			Float float32
+			Bits  uint32 `d2b:"union:true"`
+			Low   uint16 `d2b:"union:true"`
+			Tail  uint8
+		}
+		Convey("Should decode the same bytes to all union fields", func() {
+			var result Struct
+			err := Decode([]byte{1, 0x00, 0x00, 0x80, 0x3f, 2}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Struct{Tag: 1, Float: 1, Bits: 0x3f800000, Low: 0, Tail: 2})
+		})
+		Convey("Should encode the last non-zero union field", func() {
+			bytes, err := Encode(Struct{Tag: 1, Float: 1, Tail: 2}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 0x00, 0x00, 0x80, 0x3f, 2})
+
+			bytes, err = Encode(Struct{Tag: 1, Float: 1, Bits: 0x01020304, Tail: 2}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 4, 3, 2, 1, 2})
+
+			bytes, err = Encode(Struct{Tag: 1, Float: 1, Low: 0x0506, Tail: 2}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{1, 6, 5, 0x80, 0x3f, 2})
+		})
+		Convey("Should use the longest union field length", func() {
+			type Short struct {
+				A uint8
+				B uint32 `d2b:"union:true"`
+				C uint8
+			}
+			var data *Short
+			bytes, err := Encode(data, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldHaveLength, 5)
+
+			var result Short
+			err = Decode([]byte{1, 0, 0, 0, 2}, binary.LittleEndian, &result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Short{A: 1, B: 1, C: 2})
+		})
+		Convey("Should return error if the first field is union", func() {
+			type Bad struct {
+				A uint8 `d2b:"union:true"`
+			}
+			So(Decode([]byte{1}, binary.LittleEndian, &Bad{}), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package d2b
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Validate checks, that data type can be encoded and decoded without looking at its value.
+// It returns error, describing the first found problem, e.g. unsupported field type or missing length
+func Validate(data interface{}) error {
+	return NewEncoder(nil).Validate(data)
+}
+
+// Validate checks data type like Validate function, reading struct tags with encoder's TagKey
+func (e *Encoder) Validate(data interface{}) error {
+	t := reflect.TypeOf(data)
+	if t == nil {
+		return errors.New("can't validate nil")
+	}
+	return e.validateType(t)
+}
+
+func (e *Encoder) validateType(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return e.validateType(t.Elem())
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return nil
+	case reflect.Array:
+		return errors.Wrap(e.validateType(t.Elem()), "bad array element")
+	case reflect.Struct:
+		info, err := getStructInfo(t, e.TagKey)
+		if err != nil {
+			return errors.Wrapf(err, "parsing %v struct tags error", t.Name())
+		}
+		tags := info.tags
+		for i := 0; i < t.NumField(); i++ {
+			ft := t.Field(i)
+			err = e.validateStructField(ft.Type, tags[i])
+			if err != nil {
+				return errors.Wrapf(err, "%s.%s field", t.Name(), ft.Name)
+			}
+		}
+		return nil
+	}
+	return unsupportedKindError(t.Kind())
+}
+
+func (e *Encoder) validateStructField(t reflect.Type, tag *structFieldTag) error {
+	if tag.Skip || tag.Fn != "" || tag.Transform != "" || tag.CurOffset || tag.RawSelf || tag.Decimal != 0 {
+		return nil
+	}
+	if tag.Compress != "" {
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		return e.validateType(t)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return e.validateStructField(t.Elem(), tag)
+	case reflect.Slice:
+		if tag.Bits != 0 || tag.LengthASCII != 0 {
+			return nil
+		}
+		if !tag.hasLength() && !tag.Rest && tag.CountPrefix == 0 && tag.BytePrefix == 0 && tag.CountExpr == "" && tag.Terminator == "" {
+			return errors.New("need to specify length")
+		}
+		if t.Elem().Kind() == reflect.Interface && tag.TypeID != 0 {
+			return nil
+		}
+		return errors.Wrap(e.validateType(t.Elem()), "bad slice element")
+	case reflect.String:
+		if !tag.hasLength() && tag.Enum == nil && tag.PString == 0 && tag.LengthASCII == 0 {
+			return errors.New("need to specify length")
+		}
+		return nil
+	case reflect.Array:
+		if tag.Bitmap {
+			return nil
+		}
+		if tag.GUID != "" && !t.ConvertibleTo(guidType) {
+			return errors.Errorf("guid field should be [16]byte, not %v", t)
+		}
+	case reflect.Struct:
+		if tag.Flags {
+			return nil
+		}
+		if tag.NullFlag {
+			_, valueIndex, err := nullableFields(t)
+			if err != nil {
+				return err
+			}
+			tags, err := e.getStructTags(t)
+			if err != nil {
+				return err
+			}
+			return e.validateStructField(t.Field(valueIndex).Type, tags[valueIndex])
+		}
+	case reflect.Map:
+		if tag.CountPrefix == 0 {
+			return errors.New("need to specify count_prefix of map")
+		}
+		err := e.validateType(t.Key())
+		if err != nil {
+			return errors.Wrap(err, "bad map key")
+		}
+		if isBytesOrString(t.Elem()) {
+			return nil
+		}
+		return errors.Wrap(e.validateType(t.Elem()), "bad map value")
+	case reflect.Interface:
+		if tag.TypeID == 0 && tag.TypeNameFrom == "" && tag.TypeIDFrom == "" {
+			return errors.New("need to specify typeid width")
+		}
+		return nil
+	}
+	return e.validateType(t)
+}
+
+// unsupportedKindError returns error for kinds, which can't be encoded or decoded
+func unsupportedKindError(kind reflect.Kind) error {
+	switch kind {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return errors.Errorf("%v type can't be encoded or decoded, skip it with d2b:\"-\" tag", kind)
+	}
+	return errors.Errorf("type %v is not supported", kind)
+}
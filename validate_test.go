@@ -0,0 +1,73 @@
+package d2b
+
+import (
+	"testing"
+	"unsafe"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	Convey("Test Validate", t, func() {
+		Convey("Should return nil for valid struct", func() {
+			type Struct struct {
+				A  int32
+				S  string `d2b:"length:4"`
+				N  uint8
+				B  []int16   `d2b:"length_from:N"`
+				C  chan int  `d2b:"-"`
+				I  testShape `d2b:"typeid:u8"`
+				G  [16]byte  `d2b:"guid:ms"`
+				PA *[2]uint8
+			}
+			So(Validate(&Struct{}), ShouldBeNil)
+		})
+		Convey("Should return named error for channel field", func() {
+			type Struct struct {
+				A  int32
+				Ch chan int
+			}
+			err := Validate(Struct{})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, `Struct.Ch field: chan type can't be encoded or decoded, skip it with d2b:"-" tag`)
+		})
+		Convey("Should return error for function and unsafe pointer fields", func() {
+			type FuncStruct struct {
+				F func()
+			}
+			type PointerStruct struct {
+				P unsafe.Pointer
+			}
+			So(Validate(FuncStruct{}), ShouldNotBeNil)
+			So(Validate(PointerStruct{}), ShouldNotBeNil)
+		})
+		Convey("Should return error for string and slice fields without length", func() {
+			type StringStruct struct {
+				S string
+			}
+			type SliceStruct struct {
+				S []int8
+			}
+			So(Validate(StringStruct{}), ShouldNotBeNil)
+			So(Validate(SliceStruct{}), ShouldNotBeNil)
+		})
+		Convey("Should return error for nested unsupported types", func() {
+			type Inner struct {
+				A [2]int
+			}
+			type Struct struct {
+				In *Inner
+			}
+			So(Validate(Struct{}), ShouldNotBeNil)
+		})
+		Convey("Should read tags with encoder's tag key", func() {
+			type Struct struct {
+				S string `wire:"length:2"`
+			}
+			encoder := NewEncoder(nil)
+			encoder.TagKey = "wire"
+			So(encoder.Validate(Struct{}), ShouldBeNil)
+			So(Validate(Struct{}), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package d2b
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Varint formats of varint option
+const (
+	// VarintLEB128 stores 7 bits in every byte, least significant group first. High bit is a continuation flag
+	VarintLEB128 = "leb128"
+	// VarintBE7 stores 7 bits in every byte, most significant group first, like SQLite and MIDI. High bit is
+	// a continuation flag. The 9th byte holds all 8 bits, so value takes at most 9 bytes
+	VarintBE7 = "be7"
+)
+
+// checkVarint checks varint format and that field with varint option is integer
+func checkVarint(t reflect.Type, format string) error {
+	if format != VarintLEB128 && format != VarintBE7 {
+		return errors.Errorf("bad varint format %q", format)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !isInteger(t.Kind()) {
+		return errors.Errorf("varint field should be integer, not %v", t)
+	}
+	return nil
+}
+
+// decodeVarint reads varint of declared format to integer field v. Signed values are stored as their
+// 64-bit two's complement
+func (d *Decoder) decodeVarint(v reflect.Value, tags *structFieldTag) error {
+	var value uint64
+	var err error
+	if tags.Varint == VarintBE7 {
+		value, err = d.nextBE7Varint()
+	} else {
+		value, err = d.nextUvarint()
+	}
+	if err != nil {
+		return err
+	}
+	if isSigned(v.Kind()) {
+		if v.OverflowInt(int64(value)) {
+			return errors.Errorf("varint value %d overflows %v", int64(value), v.Type())
+		}
+		v.SetInt(int64(value))
+		return nil
+	}
+	if v.OverflowUint(value) {
+		return errors.Errorf("varint value %d overflows %v", value, v.Type())
+	}
+	v.SetUint(value)
+	return nil
+}
+
+// nextBE7Varint reads big-endian varint with 7 bits per byte. The 9th byte contributes all 8 bits
+func (d *Decoder) nextBE7Varint() (uint64, error) {
+	var result uint64
+	for i := 0; i < 9; i++ {
+		b, err := d.next(1)
+		if err != nil {
+			return 0, err
+		}
+		if i == 8 {
+			return result<<8 | uint64(b[0]), nil
+		}
+		result = result<<7 | uint64(b[0]&0x7f)
+		if b[0] < 0x80 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// varintToBytes writes integer field v as varint of declared format
+func varintToBytes(v reflect.Value, ft *structFieldTag, buffer *bytes.Buffer) {
+	var value uint64
+	if isSigned(v.Kind()) {
+		value = uint64(v.Int())
+	} else {
+		value = v.Uint()
+	}
+	if ft.Varint == VarintBE7 {
+		buffer.Write(be7VarintBytes(value))
+		return
+	}
+	for value >= 0x80 {
+		buffer.WriteByte(byte(value) | 0x80)
+		value >>= 7
+	}
+	buffer.WriteByte(byte(value))
+}
+
+// be7VarintBytes returns big-endian varint with 7 bits per byte. Values, which don't fit 56 bits, take
+// 9 bytes and the last byte holds 8 bits
+func be7VarintBytes(value uint64) []byte {
+	if value >= 1<<56 {
+		b := make([]byte, 9)
+		b[8] = byte(value)
+		value >>= 8
+		for i := 7; i >= 0; i-- {
+			b[i] = byte(value&0x7f) | 0x80
+			value >>= 7
+		}
+		return b
+	}
+	n := 1
+	for value>>uint(7*n) != 0 {
+		n++
+	}
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(value&0x7f) | 0x80
+		value >>= 7
+	}
+	b[n-1] &= 0x7f
+	return b
+}
@@ -0,0 +1,76 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVarint(t *testing.T) {
+	Convey("Test varint option", t, func() {
+		type Record struct {
+			Value uint64 `d2b:"varint:be7"`
+			Tail  uint8
+		}
+		Convey("Should round-trip SQLite varints", func() {
+			cases := []struct {
+				value uint64
+				bytes []byte
+			}{
+				{0, []byte{0x00}},
+				{127, []byte{0x7f}},
+				{128, []byte{0x81, 0x00}},
+				{240, []byte{0x81, 0x70}},
+				{16383, []byte{0xff, 0x7f}},
+				{16384, []byte{0x81, 0x80, 0x00}},
+				{1<<56 - 1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}},
+				{1 << 56, []byte{0x80, 0xc0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+				{1<<64 - 1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+			}
+			for _, c := range cases {
+				data := append(append([]byte(nil), c.bytes...), 0xaa)
+				b, err := Encode(Record{Value: c.value, Tail: 0xaa}, binary.LittleEndian)
+				So(err, ShouldBeNil)
+				So(b, ShouldResemble, data)
+
+				var decoded Record
+				err = Decode(data, binary.LittleEndian, &decoded)
+				So(err, ShouldBeNil)
+				So(decoded, ShouldResemble, Record{Value: c.value, Tail: 0xaa})
+			}
+		})
+		Convey("Should store signed values as two's complement", func() {
+			type Signed struct {
+				Value int64 `d2b:"varint:be7"`
+			}
+			b, err := Encode(Signed{Value: -1}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(b, ShouldHaveLength, 9)
+			var decoded Signed
+			err = Decode(b, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded.Value, ShouldEqual, -1)
+		})
+		Convey("Should encode LEB128 varints", func() {
+			type LEB struct {
+				Value uint32 `d2b:"varint:leb128"`
+			}
+			b, err := Encode(LEB{Value: 300}, binary.LittleEndian)
+			So(err, ShouldBeNil)
+			So(b, ShouldResemble, []byte{0xac, 0x02})
+			var decoded LEB
+			err = Decode(b, binary.LittleEndian, &decoded)
+			So(err, ShouldBeNil)
+			So(decoded.Value, ShouldEqual, 300)
+		})
+		Convey("Should return error if value overflows field", func() {
+			type Small struct {
+				Value uint8 `d2b:"varint:be7"`
+			}
+			var decoded Small
+			err := Decode([]byte{0x82, 0x00}, binary.LittleEndian, &decoded)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
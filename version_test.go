@@ -0,0 +1,43 @@
+package d2b
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVersion(t *testing.T) {
+	Convey("Test fields, present in range of versions", t, func() {
+		type Header struct {
+			ID       uint16
+			Flags    uint8  `d2b:"since:2"`
+			Legacy   uint16 `d2b:"until:1"`
+			Checksum uint8  `d2b:"since:2,until:3"`
+			Tail     uint8
+		}
+		Convey("Should decode fields of version 1", func() {
+			decoder := NewDecoder([]byte{0, 1, 0, 7, 9}, binary.BigEndian)
+			decoder.Version = 1
+			result := Header{Flags: 5}
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Header{ID: 1, Legacy: 7, Tail: 9})
+		})
+		Convey("Should decode fields of version 2", func() {
+			decoder := NewDecoder([]byte{0, 1, 3, 4, 9}, binary.BigEndian)
+			decoder.Version = 2
+			var result Header
+			err := decoder.Decode(&result)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, Header{ID: 1, Flags: 3, Checksum: 4, Tail: 9})
+		})
+		Convey("Should encode fields of version", func() {
+			encoder := NewEncoder(binary.BigEndian)
+			encoder.Version = 4
+			bytes, err := encoder.Encode(Header{ID: 1, Flags: 3, Legacy: 7, Checksum: 4, Tail: 9})
+			So(err, ShouldBeNil)
+			So(bytes, ShouldResemble, []byte{0, 1, 3, 9})
+		})
+	})
+}